@@ -3,15 +3,21 @@ package main
 import (
 	"fmt"
 	"github.com/richardjennings/totp/pkg/totp"
+	"log"
+	"time"
 )
 
 func main() {
 	opts := totp.Opts{
-		Timestep:        30,
-		Secret:          []byte("12345678901234567890"),
-		Digits:          8,
-		Algorithm:       totp.SHA1,
-		CurrentUnixTime: 59,
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: totp.SHA1,
+		Clock:     totp.FixedClock{Time: time.Unix(59, 0)},
 	}
-	fmt.Println(totp.GenerateTOTP(opts)) // 94287082
+	code, err := totp.GenerateTOTP(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(code) // 94287082
 }
@@ -3,7 +3,10 @@ module github.com/richardjennings/totp
 go 1.12
 
 require (
+	github.com/makiuchi-d/gozxing v0.1.1
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/cobra v1.6.1
+	golang.org/x/crypto v0.1.0
+	golang.org/x/term v0.1.0
 	google.golang.org/protobuf v1.28.1
 )
@@ -0,0 +1,27 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	for _, content := range []string{"first", "second"} {
+		if err := Write(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := os.ReadFile(path); err != nil || string(b) != content {
+			t.Errorf("expected %s got %s %v", content, b, err)
+		}
+	}
+	// no temporary files are left behind
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("expected 1 file got %v %v", entries, err)
+	}
+	if err := Write(filepath.Join(dir, "missing", "file"), nil); err == nil {
+		t.Error("expected error writing to a missing directory")
+	}
+}
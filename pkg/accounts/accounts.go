@@ -0,0 +1,140 @@
+package accounts
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/richardjennings/totp/internal/atomicfile"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// match scores, higher is a better match
+const (
+	noMatch = iota
+	subsequenceMatch
+	substringMatch
+	prefixMatch
+	exactMatch
+)
+
+// Load reads the accounts file at path, which contains one otpauth link per line. Blank lines and lines starting with
+// # are ignored. A file which does not exist contains no accounts.
+func Load(path string) (m otpauth.MigrationURI, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	s := bufio.NewScanner(f)
+	n := 0
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		uri, err := otpauth.AuthURIFromString(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		m = append(m, uri)
+	}
+	return m, s.Err()
+}
+
+// Update replaces the link of the account at index i, as returned by Load, in the accounts file at path with uri, e.g.
+// to store the advanced counter of a hotp account. Every other line, including comments and blank lines, is kept as it
+// was.
+func Update(path string, i int, uri otpauth.AuthURI) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(b), "\n")
+	n := 0
+	for j, l := range lines {
+		line := strings.TrimSpace(l)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if n == i {
+			// keep the line ending, if any
+			lines[j] = uri.URL().String() + l[len(strings.TrimRight(l, "\r\n")):]
+			return atomicfile.Write(path, []byte(strings.Join(lines, "")))
+		}
+		n++
+	}
+	return fmt.Errorf("%s: no account %d", path, i)
+}
+
+// Append adds uri to the end of the accounts file at path, creating it if required
+func Append(path string, uri otpauth.AuthURI) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(uri.URL().String() + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Find returns the indexes of the accounts in m best matching query on their issuer and label. Matching is case
+// insensitive; an exact match is preferred to a prefix, then a substring, then the characters of query appearing in
+// order. No indexes are returned when nothing matches, more than one when several accounts match equally well.
+func Find(m otpauth.MigrationURI, query string) []int {
+	best := noMatch
+	var found []int
+	q := strings.ToLower(query)
+	for i, v := range m {
+		score := noMatch
+		for _, s := range []string{v.Issuer, v.Label, v.Issuer + ":" + v.Label} {
+			if sc := match(strings.ToLower(s), q); sc > score {
+				score = sc
+			}
+		}
+		if score == noMatch || score < best {
+			continue
+		}
+		if score > best {
+			best = score
+			found = nil
+		}
+		found = append(found, i)
+	}
+	return found
+}
+
+// match scores how well s matches q
+func match(s string, q string) int {
+	switch {
+	case q == "":
+		return noMatch
+	case s == q:
+		return exactMatch
+	case strings.HasPrefix(s, q):
+		return prefixMatch
+	case strings.Contains(s, q):
+		return substringMatch
+	}
+	r := []rune(q)
+	i := 0
+	for _, c := range s {
+		if i < len(r) && r[i] == c {
+			i++
+		}
+	}
+	if i == len(r) {
+		return subsequenceMatch
+	}
+	return noMatch
+}
@@ -0,0 +1,95 @@
+package accounts
+
+import (
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	m := otpauth.MigrationURI{
+		{Issuer: "GitHub", Label: "me@example.com"},
+		{Issuer: "GitLab", Label: "me@example.com"},
+		{Issuer: "Google", Label: "work@example.com"},
+		{Issuer: "Google", Label: "home@example.com"},
+	}
+	for _, tcase := range []struct {
+		query string
+		found []int
+	}{
+		{"github", []int{0}},
+		{"git", []int{0, 1}},
+		{"lab", []int{1}},
+		{"google", []int{2, 3}},
+		{"google:work", []int{2}},
+		{"ghb", []int{0}},
+		{"work", []int{2}},
+		{"bitbucket", nil},
+	} {
+		if found := Find(m, tcase.query); !reflect.DeepEqual(found, tcase.found) {
+			t.Errorf("%s: expected %v got %v", tcase.query, tcase.found, found)
+		}
+	}
+}
+
+func TestLoadAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "totp", "accounts")
+	m, err := Load(path)
+	if err != nil || len(m) != 0 {
+		t.Fatalf("expected no accounts got %v %v", m, err)
+	}
+	uri, err := otpauth.NewAuthURI("totp@myorg", "SHA1", 6, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := Append(path, uri); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m[1].URL().String() != uri.URL().String() {
+		t.Errorf("unexpected accounts %v", m)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts")
+	content := "# work\n" +
+		"otpauth://totp/a@myorg?secret=GEZDGNBVGY3TQOJQ&pin=1234\n" +
+		"\n" +
+		"otpauth://hotp/b@myorg?counter=1&secret=GEZDGNBVGY3TQOJQ\n" +
+		"otpauth://totp/c@myorg?secret=GEZDGNBVGY3TQOJQ"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m[1].Counter = 2
+	if err := Update(path, 1, m[1]); err != nil {
+		t.Fatal(err)
+	}
+	// only the line of the updated account is rewritten
+	expected := strings.Replace(content, "otpauth://hotp/b@myorg?counter=1&secret=GEZDGNBVGY3TQOJQ", m[1].URL().String(), 1)
+	if b, err := os.ReadFile(path); err != nil || string(b) != expected {
+		t.Errorf("expected %q got %q %v", expected, b, err)
+	}
+	m[2].Issuer = "myorg"
+	if err := Update(path, 2, m[2]); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(path); err != nil || len(m) != 3 || m[1].Counter != 2 || m[2].Issuer != "myorg" {
+		t.Errorf("unexpected accounts %v %v", m, err)
+	}
+	if err := Update(path, 3, m[2]); err == nil {
+		t.Error("expected error updating a missing account")
+	}
+}
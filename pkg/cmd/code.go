@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/accounts"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/totp"
+	"github.com/spf13/cobra"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var accountsPath string
+var addLink string
+var clipboard string
+var showRemaining bool
+var showNext bool
+
+func init() {
+	codeCmd.Flags().StringVar(&accountsPath, "accounts", configPath("accounts"), "accounts <file> of otpauth links")
+	codeCmd.Flags().StringVar(&addLink, "add", "", "add <otpauth://string> to the accounts file")
+	codeCmd.Flags().StringVar(&clipboard, "clipboard", "", "clipboard <command> to copy the code to, e.g. pbcopy")
+	codeCmd.Flags().BoolVar(&showRemaining, "remaining", false, "print the seconds remaining in the period")
+	codeCmd.Flags().BoolVar(&showNext, "next", false, "print the code for the next period")
+	rootCmd.AddCommand(codeCmd)
+}
+
+var codeCmd = &cobra.Command{
+	Use:   "code <name>",
+	Short: "generate a token for an account in the accounts file, matching the name against issuer and label",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if addLink != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if addLink != "" {
+			uri, err := otpauth.AuthURIFromString(addLink)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := accounts.Append(accountsPath, uri); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		m, err := accounts.Load(accountsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		found := accounts.Find(m, args[0])
+		switch len(found) {
+		case 0:
+			log.Fatalf("no account matches %s", args[0])
+		case 1:
+		default:
+			var names []string
+			for _, i := range found {
+				names = append(names, fmt.Sprintf("%s (%s)", m[i].Issuer, m[i].Label))
+			}
+			log.Fatalf("%s matches more than one account: %s", args[0], strings.Join(names, ", "))
+		}
+		i := found[0]
+		uri := m[i]
+
+		var code string
+		var lines []string
+		if uri.Type == "hotp" {
+			if showNext || showRemaining {
+				log.Fatal(errors.New("--next and --remaining are not supported for hotp accounts"))
+			}
+			code, m[i], err = otpauth.GenerateHOTPFromAuthURI(uri)
+			if err != nil {
+				log.Fatal(err)
+			}
+			// store the advanced counter so that the code is not generated again
+			if err := accounts.Update(accountsPath, i, m[i]); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			uri = withPIN(uri)
+			now := time.Now()
+			code, err = otpauth.GenerateTOTPFromAuthURI(uri, totp.FixedClock{Time: now})
+			if err != nil {
+				log.Fatal(err)
+			}
+			if showNext {
+				next, err := otpauth.GenerateTOTPFromAuthURI(uri, totp.FixedClock{Time: now.Add(time.Duration(uri.Period) * time.Second)})
+				if err != nil {
+					log.Fatal(err)
+				}
+				lines = append(lines, fmt.Sprintf("next %s", next))
+			}
+			if showRemaining {
+				lines = append(lines, fmt.Sprintf("%ds remaining", int64(uri.Period)-(now.Unix()-int64(uri.InitialCounterTime))%int64(uri.Period)))
+			}
+		}
+		fmt.Println(code)
+		for _, l := range lines {
+			fmt.Println(l)
+		}
+		if clipboard != "" {
+			if err := copyToClipboard(clipboard, code); err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+// copyToClipboard runs command, e.g. pbcopy or "xclip -selection clipboard", with s as its standard input
+func copyToClipboard(command string, s string) error {
+	f := strings.Fields(command)
+	if len(f) == 0 {
+		return errors.New("clipboard command required")
+	}
+	c := exec.Command(f[0], f[1:]...)
+	c.Stdin = strings.NewReader(s)
+	return c.Run()
+}
@@ -3,10 +3,8 @@ package cmd
 import (
 	"fmt"
 	"github.com/richardjennings/totp/pkg/otpauth"
-	"github.com/skip2/go-qrcode"
 	"github.com/spf13/cobra"
 	"log"
-	"os"
 )
 
 var issuer string
@@ -16,6 +14,7 @@ var encodedSecret string
 var algo string
 var digits int
 var period int
+var t0 uint64
 
 var pngQr string
 
@@ -27,8 +26,10 @@ func init() {
 	genCmd.Flags().StringVar(&algo, "algorithm", "SHA1", "Algorithm")
 	genCmd.Flags().IntVar(&digits, "digits", 6, "Number of digits")
 	genCmd.Flags().IntVar(&period, "period", 30, "Period")
+	genCmd.Flags().Uint64Var(&t0, "t0", 0, "Unix time to count periods from")
 
 	genCmd.Flags().StringVar(&pngQr, "qr-png", "", "qr-png <file>")
+	addTerminalQrFlags(genCmd)
 	rootCmd.AddCommand(genCmd)
 }
 
@@ -44,21 +45,17 @@ var genCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalln(err)
 		}
+		uri.InitialCounterTime = t0
 		link := uri.URL().String()
-		code, err := otpauth.GenerateTOTPFromAuthURI(uri, timestamp)
+		code, err := otpauth.GenerateTOTPFromAuthURI(uri, timestampClock(timestamp))
 		if err != nil {
 			log.Fatalln(err)
 		}
 		fmt.Println(link)
 		fmt.Println(code)
+		writeTerminalQr(link)
 		if pngQr != "" {
-			png, err := qrcode.Encode(link, qrcode.Medium, 256)
-			if err != nil {
-				log.Fatalln(err)
-			}
-			if err := os.WriteFile(pngQr, png, 0777); err != nil {
-				log.Fatalln(err)
-			}
+			writePngQr(pngQr, link)
 		}
 
 	},
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+func init() {
+	rootCmd.AddCommand(hotpCmd)
+}
+
+var hotpCmd = &cobra.Command{
+	Use:   "hotp <otpauth://hotp/string>",
+	Short: "generate the next HOTP token from an otpauth://hotp URI and print the URI with the updated counter",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		otpAuthUri, err := otpauth.AuthURIFromString(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		code, next, err := otpauth.GenerateHOTPFromAuthURI(otpAuthUri)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s %s\n", next.Label, code)
+		fmt.Printf("counter %d\n", next.Counter)
+		fmt.Println(next.URL().String())
+	},
+}
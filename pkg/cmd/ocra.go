@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/ocra"
+	"github.com/richardjennings/totp/pkg/totp"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+var (
+	ocraKey       string
+	ocraChallenge string
+	ocraCounter   uint64
+	ocraPin       string
+	ocraSession   string
+	ocraVerify    string
+)
+
+func init() {
+	ocraCmd.Flags().StringVar(&ocraKey, "key", "", "hex encoded key")
+	ocraCmd.Flags().StringVar(&ocraChallenge, "challenge", "", "challenge")
+	ocraCmd.Flags().Uint64Var(&ocraCounter, "counter", 0, "counter for suites with C")
+	ocraCmd.Flags().StringVar(&ocraPin, "pin", "", "PIN for suites with P")
+	ocraCmd.Flags().StringVar(&ocraSession, "session", "", "hex encoded session information for suites with S")
+	ocraCmd.Flags().StringVarP(&timestamp, "timestamp", "t", "", "Specify a Unix timestamp for suites with T")
+	ocraCmd.Flags().StringVar(&ocraVerify, "verify", "", "verify <response> instead of generating one")
+	_ = ocraCmd.MarkFlagRequired("key")
+	_ = ocraCmd.MarkFlagRequired("challenge")
+	rootCmd.AddCommand(ocraCmd)
+}
+
+var ocraCmd = &cobra.Command{
+	Use:   "ocra <suite>",
+	Short: "generate or verify an OCRA (RFC 6287) response, e.g. for suite OCRA-1:HOTP-SHA1-6:QN08",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		suite, err := ocra.ParseSuite(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		key, err := hex.DecodeString(ocraKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		session, err := hex.DecodeString(ocraSession)
+		if err != nil {
+			log.Fatal(err)
+		}
+		in := ocra.Input{
+			Counter:   ocraCounter,
+			Challenge: ocraChallenge,
+			PIN:       ocraPin,
+			Session:   session,
+			Clock:     timestampClock(timestamp),
+		}
+		if ocraVerify != "" {
+			if _, err := suite.Verify(key, in, ocraVerify, totp.Window{}); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("valid")
+			return
+		}
+		response, err := suite.Generate(key, in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(response)
+	},
+}
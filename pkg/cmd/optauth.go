@@ -3,30 +3,79 @@ package cmd
 import (
 	"fmt"
 	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/qr"
+	"github.com/richardjennings/totp/pkg/totp"
 	"github.com/spf13/cobra"
 	"log"
+	"strconv"
+	"time"
 )
 
+// pinEnv is the environment variable from which the PIN of motp and yaotp accounts is read, if set
+const pinEnv = "TOTP_PIN"
+
 var timestamp string
+var otpAuthQrImage string
 
 func init() {
 	otpAuth.Flags().StringVarP(&timestamp, "timestamp", "t", "", "Specify a Unix timestamp")
+	otpAuth.Flags().Uint64Var(&t0, "t0", 0, "Unix time to count periods from, overriding the t0 of the URI")
+	otpAuth.Flags().StringVar(&otpAuthQrImage, "qr-image", "", "qr-image <file> PNG or JPEG image of an otpauth QR code")
 	rootCmd.AddCommand(otpAuth)
 }
 
 var otpAuth = &cobra.Command{
 	Use:   "otpauth <otpauth://string>",
 	Short: "generate a TOTP token from an otpauth:// URI",
-	Args:  cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if otpAuthQrImage != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		otpAuthUri, err := otpauth.AuthURIFromString(args[0])
+		var link string
+		if otpAuthQrImage != "" {
+			var err error
+			link, err = qr.DecodeFile(otpAuthQrImage)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			link = args[0]
+		}
+		otpAuthUri, err := otpauth.AuthURIFromString(link)
 		if err != nil {
 			log.Fatal(err)
 		}
-		code, err := otpauth.GenerateTOTPFromAuthURI(otpAuthUri, timestamp)
+		if cmd.Flags().Changed("t0") {
+			otpAuthUri.InitialCounterTime = t0
+		}
+		code, err := otpauth.GenerateTOTPFromAuthURI(withPIN(otpAuthUri), timestampClock(timestamp))
 		if err != nil {
 			log.Fatal(err)
 		}
 		fmt.Printf("%s %s\n", otpAuthUri.Label, code)
 	},
 }
+
+// withPIN returns uri with the PIN read from TOTP_PIN, or else as readSecret does, when it is a motp or yaotp AuthURI
+// without a PIN
+func withPIN(uri otpauth.AuthURI) otpauth.AuthURI {
+	if (uri.Type == "motp" || uri.Type == "yaotp") && uri.PIN == "" {
+		uri.PIN = string(readSecret(pinEnv, "PIN: "))
+	}
+	return uri
+}
+
+// timestampClock returns a Clock fixed at the Unix time timestamp, or nil for the system time when it is empty
+func timestampClock(timestamp string) totp.Clock {
+	if timestamp == "" {
+		return nil
+	}
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return totp.FixedClock{Time: time.Unix(t, 0)}
+}
@@ -3,17 +3,22 @@ package cmd
 import (
 	"fmt"
 	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/qr"
 	"github.com/spf13/cobra"
 	"log"
 	"net/url"
+	"os"
 )
 
 var migrateUri []string
 var generateTotp bool
+var migrateQrImage []string
 
 func init() {
 	otpMigrate.Flags().StringSliceVarP(&migrateUri, "link", "l", []string{}, "Specify Migration Link")
 	otpMigrate.Flags().BoolVar(&generateTotp, "totp", false, "generate totp codes")
+	otpMigrate.Flags().StringSliceVar(&migrateQrImage, "qr-image", []string{}, "qr-image <file> PNG or JPEG image of a Migration QR code")
+	addTerminalQrFlags(otpMigrate)
 	rootCmd.AddCommand(otpMigrate)
 }
 
@@ -21,27 +26,61 @@ var otpMigrate = &cobra.Command{
 	Use:   "otpmigrate <otpauth-migration://string>",
 	Short: "generate otpauth URIs from an optauth-migration URI",
 	Run: func(cmd *cobra.Command, args []string) {
-
+		var links []*url.URL
 		for _, v := range migrateUri {
 			m, err := url.Parse(v)
 			if err != nil {
 				log.Fatal(err)
 			}
-			mUri, err := otpauth.MigrationURIDecode(m)
+			links = append(links, m)
+		}
+		for _, v := range migrateQrImage {
+			text, err := qr.DecodeFile(v)
+			if err != nil {
+				log.Fatalf("%s: %s", v, err)
+			}
+			m, err := url.Parse(text)
 			if err != nil {
 				log.Fatal(err)
 			}
-			if generateTotp {
-				for _, v := range mUri {
-					c, err := otpauth.GenerateTOTPFromAuthURI(v, "")
-					if err != nil {
-						log.Fatal(err)
-					}
-					fmt.Printf("%s - %s (%s) \n", c, v.Issuer, v.Label)
+			links = append(links, m)
+		}
+		mUri, summary, err := otpauth.MigrationURIImport(links)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, b := range summary.Batches {
+			if len(b.Missing) > 0 {
+				fmt.Fprintf(os.Stderr, "batch %d: missing links %v of %d\n", b.BatchId, b.Missing, b.BatchSize)
+			}
+			if len(b.Duplicate) > 0 {
+				fmt.Fprintf(os.Stderr, "batch %d: duplicate links %v\n", b.BatchId, b.Duplicate)
+			}
+		}
+		if summary.DuplicateAccounts > 0 {
+			fmt.Fprintf(os.Stderr, "%d duplicate accounts ignored\n", summary.DuplicateAccounts)
+		}
+		if generateTotp {
+			for _, v := range mUri {
+				var c string
+				if v.Type == "hotp" {
+					c, _, err = otpauth.GenerateHOTPFromAuthURI(v)
+				} else {
+					c, err = otpauth.GenerateTOTPFromAuthURI(v, nil)
+				}
+				if err != nil {
+					log.Fatal(err)
 				}
-			} else {
-				fmt.Println(mUri)
+				fmt.Printf("%s - %s (%s) \n", c, v.Issuer, v.Label)
+			}
+		} else if terminalQr {
+			for _, v := range mUri {
+				link := v.URL().String()
+				fmt.Println(link)
+				writeTerminalQr(link)
 			}
+		} else {
+			fmt.Println(mUri)
 		}
 	},
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/spf13/cobra"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+var exportPngQr string
+
+func init() {
+	otpMigrateExport.Flags().StringVar(&exportPngQr, "qr-png", "", "qr-png <file>, numbered when more than one link is created")
+	addTerminalQrFlags(otpMigrateExport)
+	otpMigrate.AddCommand(otpMigrateExport)
+}
+
+var otpMigrateExport = &cobra.Command{
+	Use:   "export <otpauth://string>...",
+	Short: "generate otpauth-migration URIs from otpauth URIs",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var m otpauth.MigrationURI
+		for _, v := range args {
+			uri, err := otpauth.AuthURIFromString(v)
+			if err != nil {
+				log.Fatal(err)
+			}
+			m = append(m, uri)
+		}
+		links, err := otpauth.MigrationURIEncode(m)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, v := range links {
+			link := v.String()
+			fmt.Println(link)
+			writeTerminalQr(link)
+			if exportPngQr == "" {
+				continue
+			}
+			file := exportPngQr
+			if len(links) > 1 {
+				ext := filepath.Ext(file)
+				file = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(file, ext), i+1, ext)
+			}
+			writePngQr(file, link)
+		}
+	},
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/richardjennings/totp/pkg/qr"
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var terminalQr bool
+var terminalQrOpts qr.TerminalOpts
+
+// addTerminalQrFlags adds the flags for rendering QR codes in the terminal to cmd
+func addTerminalQrFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&terminalQr, "qr-terminal", false, "render QR codes in the terminal")
+	cmd.Flags().BoolVar(&terminalQrOpts.ANSI, "qr-ansi", false, "render terminal QR codes with ANSI colours instead of Unicode half blocks")
+	cmd.Flags().BoolVar(&terminalQrOpts.Invert, "qr-invert", false, "invert terminal QR codes for terminals with dark text on a light background")
+	cmd.Flags().IntVar(&terminalQrOpts.QuietZone, "qr-quiet-zone", 4, "width in modules of the border around terminal QR codes")
+}
+
+// writeTerminalQr renders content as a QR code to standard output when --qr-terminal is set
+func writeTerminalQr(content string) {
+	if !terminalQr {
+		return
+	}
+	if err := qr.WriteTerminal(os.Stdout, content, terminalQrOpts); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// writePngQr writes content as a QR code PNG image to file, readable only by the user as it may contain a secret
+func writePngQr(file string, content string) {
+	png, err := qrcode.Encode(content, qrcode.Medium, 256)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.WriteFile(file, png, 0600); err != nil {
+		log.Fatalln(err)
+	}
+}
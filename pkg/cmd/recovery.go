@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/recovery"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+var (
+	recoveryCount  int
+	recoveryHashes string
+)
+
+func init() {
+	recoveryCmd.Flags().IntVar(&recoveryCount, "count", recovery.DefaultCount, "Number of recovery codes")
+	recoveryCmd.PersistentFlags().StringVar(&recoveryHashes, "hashes", "", "hashes <file>")
+	recoveryCmd.AddCommand(recoveryUse)
+	rootCmd.AddCommand(recoveryCmd)
+}
+
+var recoveryCmd = &cobra.Command{
+	Use:   "recovery",
+	Short: "generate a printable set of single-use recovery codes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		codes, hashes, err := recovery.Generate(recoveryCount)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, code := range codes {
+			fmt.Printf("%2d. %s\n", i+1, code)
+		}
+		if recoveryHashes != "" {
+			writeRecoveryHashes(hashes)
+		}
+	},
+}
+
+var recoveryUse = &cobra.Command{
+	Use:   "use <code>",
+	Short: "consume a recovery code from a hashes file and print how many remain",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if recoveryHashes == "" {
+			log.Fatal("--hashes is required")
+		}
+		b, err := os.ReadFile(recoveryHashes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var hashes []recovery.Hash
+		if err := json.Unmarshal(b, &hashes); err != nil {
+			log.Fatal(err)
+		}
+		remaining, err := recovery.Use(hashes, args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		writeRecoveryHashes(remaining)
+		fmt.Printf("%d remaining\n", len(remaining))
+	},
+}
+
+// writeRecoveryHashes writes hashes as JSON to the --hashes file
+func writeRecoveryHashes(hashes []recovery.Hash) {
+	b, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(recoveryHashes, b, 0600); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,13 +2,28 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/richardjennings/totp/pkg/totp"
 	"github.com/spf13/cobra"
 	"os"
 )
 
+var enableAlgorithms []string
+
+func init() {
+	rootCmd.PersistentFlags().StringSliceVar(&enableAlgorithms, "enable-algorithm", nil, "enable algorithms which are disabled by default: MD5, SHA3-256, SHA3-512")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "totp",
 	Short: "Totp is a CLI tool for generating TOTP codes",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range enableAlgorithms {
+			if err := totp.EnableAlgorithm(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		cobra.CheckErr(cmd.Help())
 	},
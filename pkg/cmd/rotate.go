@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+func init() {
+	rotateCmd.Flags().StringVar(&pngQr, "qr-png", "", "qr-png <file>")
+	addTerminalQrFlags(rotateCmd)
+	rootCmd.AddCommand(rotateCmd)
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <otpauth://string>",
+	Short: "generate a new secret for an account, printing its otpauth URI and optionally a QR code",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		uri, err := otpauth.AuthURIFromString(args[0])
+		if err != nil {
+			log.Fatalln(err)
+		}
+		uri, err = uri.Rotate()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		link := uri.URL().String()
+		fmt.Println(uri.EncodedSecret())
+		fmt.Println(link)
+		writeTerminalQr(link)
+		if pngQr != "" {
+			writePngQr(pngQr, link)
+		}
+	},
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+func init() {
+	secretNew.Flags().StringVar(&issuer, "issuer", "", "Issuer")
+	secretNew.Flags().StringVar(&label, "label", "", "Label")
+	secretNew.Flags().StringVar(&algo, "algorithm", "SHA1", "Algorithm")
+	secretNew.Flags().IntVar(&digits, "digits", 6, "Number of digits")
+	secretNew.Flags().IntVar(&period, "period", 30, "Period")
+	secretNew.Flags().StringVar(&pngQr, "qr-png", "", "qr-png <file>")
+	addTerminalQrFlags(secretNew)
+	secretCmd.AddCommand(secretNew)
+	rootCmd.AddCommand(secretCmd)
+}
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "manage shared secrets",
+}
+
+var secretNew = &cobra.Command{
+	Use:   "new",
+	Short: "generate a random shared secret and its otpauth URI",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		uri, err := otpauth.NewRandomAuthURI(label, algo, digits, issuer, period)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		link := uri.URL().String()
+		fmt.Println(uri.EncodedSecret())
+		fmt.Println(link)
+		writeTerminalQr(link)
+		if pngQr != "" {
+			writePngQr(pngQr, link)
+		}
+	},
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/qr"
+	"github.com/richardjennings/totp/pkg/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vaultPassphraseEnv is the environment variable from which the vault passphrase is read, if set
+const vaultPassphraseEnv = "TOTP_VAULT_PASSPHRASE"
+
+var vaultPath string
+var vaultQrImage string
+
+// stdin is shared so that secrets read from successive lines of standard input are not lost to buffering
+var stdin = bufio.NewReader(os.Stdin)
+
+func init() {
+	vaultCmd.PersistentFlags().StringVar(&vaultPath, "vault", configPath("vault.json"), "vault <file>")
+	vaultAdd.Flags().StringVar(&vaultQrImage, "qr-image", "", "qr-image <file> PNG or JPEG image of an otpauth QR code")
+	vaultCmd.AddCommand(vaultInit, vaultAdd, vaultList, vaultRemove, vaultRename, vaultCode)
+	rootCmd.AddCommand(vaultCmd)
+}
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "manage accounts stored in an encrypted vault",
+}
+
+var vaultInit = &cobra.Command{
+	Use:   "init",
+	Short: "create an empty vault",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := vault.Create(vaultPath, readPassphrase()); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var vaultAdd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "add an account to the vault, reading its otpauth link from a prompt, standard input or a QR code image",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var link string
+		if vaultQrImage != "" {
+			var err error
+			if link, err = qr.DecodeFile(vaultQrImage); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			// the link contains the secret, so it is not taken from the command line where it would be kept in the
+			// shell history
+			link = string(readSecret("", "otpauth link: "))
+		}
+		uri, err := otpauth.AuthURIFromString(link)
+		if err != nil {
+			log.Fatal(err)
+		}
+		v := openVault()
+		if err := v.Add(args[0], uri); err != nil {
+			log.Fatal(err)
+		}
+		if err := v.Save(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var vaultList = &cobra.Command{
+	Use:   "list",
+	Short: "list the accounts in the vault",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, e := range openVault().List() {
+			fmt.Printf("%s - %s (%s)\n", e.Name, e.URI.Issuer, e.URI.Label)
+		}
+	},
+}
+
+var vaultRemove = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "remove an account from the vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v := openVault()
+		if err := v.Remove(args[0]); err != nil {
+			log.Fatal(err)
+		}
+		if err := v.Save(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var vaultRename = &cobra.Command{
+	Use:   "rename <name> <new name>",
+	Short: "rename an account in the vault",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		v := openVault()
+		if err := v.Rename(args[0], args[1]); err != nil {
+			log.Fatal(err)
+		}
+		if err := v.Save(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var vaultCode = &cobra.Command{
+	Use:   "code <name>",
+	Short: "generate a token for an account in the vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v := openVault()
+		uri, err := v.Get(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		var code string
+		if uri.Type == "hotp" {
+			var next otpauth.AuthURI
+			code, next, err = otpauth.GenerateHOTPFromAuthURI(uri)
+			if err != nil {
+				log.Fatal(err)
+			}
+			// store the advanced counter so that the code is not generated again
+			if err := v.Set(args[0], next); err != nil {
+				log.Fatal(err)
+			}
+			if err := v.Save(); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			code, err = otpauth.GenerateTOTPFromAuthURI(withPIN(uri), nil)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		fmt.Println(code)
+	},
+}
+
+// openVault opens the vault, prompting for the passphrase
+func openVault() *vault.Vault {
+	v, err := vault.Open(vaultPath, readPassphrase())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return v
+}
+
+// readPassphrase reads the vault passphrase from TOTP_VAULT_PASSPHRASE when set, or else as readSecret does
+func readPassphrase() []byte {
+	return readSecret(vaultPassphraseEnv, "Passphrase: ")
+}
+
+// readSecret reads a secret such as a passphrase or PIN from the environment variable env when set, or else from
+// standard input, without echo after prompting when it is a terminal. No environment variable is read when env is empty
+func readSecret(env string, prompt string) []byte {
+	if p := os.Getenv(env); p != "" {
+		return []byte(p)
+	}
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		p, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return p
+	}
+	p, err := stdin.ReadString('\n')
+	if err != nil && p == "" {
+		log.Fatal(err)
+	}
+	return []byte(strings.TrimRight(p, "\r\n"))
+}
+
+// configPath returns the path of a file in the totp user configuration directory, e.g. ~/.config/totp/name
+func configPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(dir, "totp", name)
+}
@@ -0,0 +1,238 @@
+package enroll
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/qr"
+	"github.com/richardjennings/totp/pkg/totp"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a pending enrollment may be confirmed for when Enroller.TTL is 0
+const DefaultTTL = 10 * time.Minute
+
+var (
+	// ErrNotFound is returned when there is no pending enrollment with an id
+	ErrNotFound = errors.New("enrollment not found")
+	// ErrExpired is returned when a pending enrollment is confirmed after it has expired
+	ErrExpired = errors.New("enrollment expired")
+	// ErrConfirmations is returned when the number of confirmation codes is not Enroller.Confirmations
+	ErrConfirmations = errors.New("wrong number of confirmation codes")
+)
+
+type (
+	// Enrollment is a pending enrollment of an account which is activated once confirmed
+	Enrollment struct {
+		// identifies the enrollment to Confirm
+		ID string
+		// the account being enrolled, including the new secret
+		URI otpauth.AuthURI
+		// the enrollment cannot be confirmed after Expires
+		Expires time.Time
+	}
+
+	// Store stores pending enrollments
+	Store interface {
+		// Put stores e, replacing any enrollment with the same ID
+		Put(e Enrollment) error
+		// Get returns the enrollment with id, or ErrNotFound
+		Get(id string) (Enrollment, error)
+		// Delete removes the enrollment with id, if any
+		Delete(id string) error
+		// DeleteExpired removes every enrollment which expired before t
+		DeleteExpired(t time.Time) error
+	}
+
+	// MemoryStore is a Store held in memory
+	MemoryStore struct {
+		mu          sync.Mutex
+		enrollments map[string]Enrollment
+	}
+
+	// Enroller provisions new TOTP accounts, which are returned for storage only once the user has shown that their
+	// authenticator generates the right codes.
+	Enroller struct {
+		// stores pending enrollments
+		Store Store
+		// the algorithm of new accounts. The default is SHA1
+		Algorithm string
+		// the digits of new accounts. The default is 6
+		Digits int
+		// the period of new accounts in seconds. The default is 30
+		Period int
+		// how long a pending enrollment may be confirmed for. DefaultTTL is used when 0
+		TTL time.Duration
+		// the number of consecutive codes required to confirm an enrollment, 1 or 2. The default is 1
+		Confirmations int
+		// the window of time steps around the current time step in which the last confirmation code is accepted
+		Window totp.Window
+		// provides the current time. The system time is used when nil
+		Clock totp.Clock
+	}
+)
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{enrollments: map[string]Enrollment{}}
+}
+
+// Put implements Store
+func (s *MemoryStore) Put(e Enrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enrollments[e.ID] = e
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(id string) (Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.enrollments[id]
+	if !ok {
+		return Enrollment{}, ErrNotFound
+	}
+	return e, nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enrollments, id)
+	return nil
+}
+
+// DeleteExpired implements Store
+func (s *MemoryStore) DeleteExpired(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.enrollments {
+		if t.After(e.Expires) {
+			delete(s.enrollments, id)
+		}
+	}
+	return nil
+}
+
+// Link returns the otpauth link of the enrollment for the user to add to their authenticator
+func (e Enrollment) Link() string {
+	return e.URI.URL().String()
+}
+
+// PNG renders the otpauth link as a QR code PNG image of size by size pixels
+func (e Enrollment) PNG(size int) ([]byte, error) {
+	return qr.PNG(e.Link(), size)
+}
+
+// SVG renders the otpauth link as a QR code SVG image
+func (e Enrollment) SVG() (string, error) {
+	return qr.SVG(e.Link())
+}
+
+// DataURI renders the otpauth link as a QR code PNG image of size by size pixels in a data URI
+func (e Enrollment) DataURI(size int) (string, error) {
+	return qr.DataURI(e.Link(), size)
+}
+
+// Begin creates and stores a pending enrollment for a new account with a fresh secret. Expired pending enrollments
+// are removed from the Store.
+func (en Enroller) Begin(label string, issuer string) (Enrollment, error) {
+	t := en.now()
+	if err := en.Store.DeleteExpired(t); err != nil {
+		return Enrollment{}, err
+	}
+	algo := en.Algorithm
+	if algo == "" {
+		algo = "SHA1"
+	}
+	digits := en.Digits
+	if digits == 0 {
+		digits = 6
+	}
+	period := en.Period
+	if period == 0 {
+		period = 30
+	}
+	uri, err := otpauth.NewRandomAuthURI(label, algo, digits, issuer, period)
+	if err != nil {
+		return Enrollment{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Enrollment{}, err
+	}
+	ttl := en.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	e := Enrollment{ID: id, URI: uri, Expires: t.Add(ttl)}
+	if err := en.Store.Put(e); err != nil {
+		return Enrollment{}, err
+	}
+	return e, nil
+}
+
+// Confirm checks codes from the user's authenticator against the pending enrollment id. When two codes are required
+// they must be for consecutive time steps, the last within Window. On success the pending enrollment is removed and
+// the activated AuthURI is returned for storage. On failure with totp.ErrInvalidCode the enrollment remains pending,
+// so callers should throttle attempts, e.g. with verify.Verifier.
+func (en Enroller) Confirm(id string, codes ...string) (otpauth.AuthURI, error) {
+	confirmations := en.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	if confirmations > 2 || len(codes) != confirmations {
+		return otpauth.AuthURI{}, ErrConfirmations
+	}
+	e, err := en.Store.Get(id)
+	if err != nil {
+		return otpauth.AuthURI{}, err
+	}
+	// freeze the time so that every code is checked against the same current time step
+	t := en.now()
+	if t.After(e.Expires) {
+		if err := en.Store.Delete(id); err != nil {
+			return otpauth.AuthURI{}, err
+		}
+		return otpauth.AuthURI{}, ErrExpired
+	}
+	opts, err := e.URI.TOTPOpts(totp.FixedClock{Time: t})
+	if err != nil {
+		return otpauth.AuthURI{}, err
+	}
+	offset, err := totp.Validate(opts, codes[len(codes)-1], en.Window)
+	if err != nil {
+		return otpauth.AuthURI{}, err
+	}
+	if len(codes) == 2 {
+		// the first code must be for the time step immediately before the last
+		if _, err := totp.ValidateWithDrift(opts, codes[0], totp.Window{}, offset-1); err != nil {
+			return otpauth.AuthURI{}, err
+		}
+	}
+	if err := en.Store.Delete(id); err != nil {
+		return otpauth.AuthURI{}, err
+	}
+	return e.URI, nil
+}
+
+// now returns the current time of Clock, or the system time when nil
+func (en Enroller) now() time.Time {
+	if en.Clock == nil {
+		return time.Now()
+	}
+	return en.Clock.Now()
+}
+
+// newID returns a random enrollment id
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
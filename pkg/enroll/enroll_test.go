@@ -0,0 +1,125 @@
+package enroll
+
+import (
+	"bytes"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"github.com/richardjennings/totp/pkg/qr"
+	"github.com/richardjennings/totp/pkg/totp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// code returns the code for the enrollment at time t
+func code(t *testing.T, e Enrollment, at time.Time) string {
+	t.Helper()
+	c, err := otpauth.GenerateTOTPFromAuthURI(e.URI, totp.FixedClock{Time: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConfirm(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	en := Enroller{Store: NewMemoryStore(), Window: totp.Window{Behind: 1}, Clock: totp.FixedClock{Time: now}}
+	e, err := en.Begin("alice", "myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.URI.Label != "alice" || e.URI.Issuer != "myorg" || e.URI.Digits != 6 || e.URI.Period != 30 {
+		t.Errorf("unexpected AuthURI %v", e.URI)
+	}
+	if !e.Expires.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("expected expiry %v got %v", now.Add(DefaultTTL), e.Expires)
+	}
+	if _, err := en.Confirm(e.ID, code(t, e, now.Add(time.Minute))); err != totp.ErrInvalidCode {
+		t.Errorf("expected %v got %v", totp.ErrInvalidCode, err)
+	}
+	// a failed confirmation leaves the enrollment pending
+	uri, err := en.Confirm(e.ID, code(t, e, now.Add(-30*time.Second)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.URL().String() != e.Link() {
+		t.Errorf("expected %s got %s", e.Link(), uri.URL().String())
+	}
+	if _, err := en.Confirm(e.ID, code(t, e, now)); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+}
+
+func TestConfirmConsecutive(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	en := Enroller{Store: NewMemoryStore(), Confirmations: 2, Clock: totp.FixedClock{Time: now}}
+	e, err := en.Begin("alice", "myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, current := code(t, e, now.Add(-30*time.Second)), code(t, e, now)
+	if _, err := en.Confirm(e.ID, current); err != ErrConfirmations {
+		t.Errorf("expected %v got %v", ErrConfirmations, err)
+	}
+	if _, err := en.Confirm(e.ID, current, previous); err != totp.ErrInvalidCode {
+		t.Errorf("expected %v got %v", totp.ErrInvalidCode, err)
+	}
+	if _, err := en.Confirm(e.ID, current, current); err != totp.ErrInvalidCode {
+		t.Errorf("expected %v got %v", totp.ErrInvalidCode, err)
+	}
+	if _, err := en.Confirm(e.ID, previous, current); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	store := NewMemoryStore()
+	en := Enroller{Store: store, TTL: time.Minute, Clock: totp.FixedClock{Time: now}}
+	stale, err := en.Begin("alice", "myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	en.Clock = totp.FixedClock{Time: now.Add(2 * time.Minute)}
+	if _, err := en.Confirm(stale.ID, code(t, stale, now.Add(2*time.Minute))); err != ErrExpired {
+		t.Errorf("expected %v got %v", ErrExpired, err)
+	}
+	if _, err := store.Get(stale.ID); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+	// stale enrollments are removed when a new enrollment begins
+	en.Clock = totp.FixedClock{Time: now}
+	stale, err = en.Begin("alice", "myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	en.Clock = totp.FixedClock{Time: now.Add(2 * time.Minute)}
+	if _, err := en.Begin("bob", "myorg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(stale.ID); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	en := Enroller{Store: NewMemoryStore()}
+	e, err := en.Begin("alice", "myorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := e.PNG(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link, err := qr.Decode(bytes.NewReader(b)); err != nil || link != e.Link() {
+		t.Errorf("expected %s got %s %v", e.Link(), link, err)
+	}
+	s, err := e.SVG()
+	if err != nil || !strings.HasPrefix(s, "<svg") {
+		t.Errorf("unexpected svg %s %v", s, err)
+	}
+	s, err = e.DataURI(256)
+	if err != nil || !strings.HasPrefix(s, "data:image/png;base64,") {
+		t.Errorf("unexpected data uri %s %v", s, err)
+	}
+}
@@ -0,0 +1,88 @@
+package hotp
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+type (
+	// Generator generates HOTPs for one secret, producing the same codes as GenerateHOTPEncoded. The keyed HMAC
+	// state and buffers are created once and reused, which suits verifying many codes or whole windows of counters.
+	// A Generator is safe for concurrent use, each goroutine borrowing its own HMAC state from a pool.
+	Generator struct {
+		length  uint
+		encoder Encoder
+		states  sync.Pool
+	}
+
+	// generatorState is the HMAC state and buffers used by one goroutine at a time
+	generatorState struct {
+		mac     hash.Hash
+		counter [8]byte
+		sum     []byte
+	}
+)
+
+// NewGenerator creates a Generator of codes of length symbols rendered by encoder, or decimal digits when encoder is
+// nil
+func NewGenerator(hash func() hash.Hash, secret []byte, length uint, encoder Encoder) (*Generator, error) {
+	if hash == nil {
+		return nil, ErrInvalidHash
+	}
+	if encoder == nil {
+		encoder = Decimal
+	}
+	// check the length is supported by the encoder
+	if _, err := encoder(0, length); err != nil {
+		return nil, err
+	}
+	key := append([]byte(nil), secret...)
+	mac := hmac.New(hash, key)
+	if mac.Size() < 20 {
+		return nil, ErrInvalidHash
+	}
+	g := &Generator{length: length, encoder: encoder}
+	g.states.New = func() interface{} {
+		mac := hmac.New(hash, key)
+		return &generatorState{mac: mac, sum: make([]byte, 0, mac.Size())}
+	}
+	g.states.Put(&generatorState{mac: mac, sum: make([]byte, 0, mac.Size())})
+	return g, nil
+}
+
+// Generate returns the code for counter
+func (g *Generator) Generate(counter uint64) (string, error) {
+	s := g.states.Get().(*generatorState)
+	defer g.states.Put(s)
+	return g.generate(s, counter)
+}
+
+// GenerateWindow appends the codes for n counters starting at counter to dst, e.g. the look-ahead window of RFC 4226
+// section 7.4, and returns the extended slice
+func (g *Generator) GenerateWindow(dst []string, counter uint64, n uint) ([]string, error) {
+	s := g.states.Get().(*generatorState)
+	defer g.states.Put(s)
+	for i := uint64(0); i < uint64(n); i++ {
+		code, err := g.generate(s, counter+i)
+		if err != nil {
+			return dst, err
+		}
+		dst = append(dst, code)
+	}
+	return dst, nil
+}
+
+// generate returns the code for counter using the HMAC state and buffers of s
+func (g *Generator) generate(s *generatorState, counter uint64) (string, error) {
+	s.mac.Reset()
+	binary.BigEndian.PutUint64(s.counter[:], counter)
+	s.mac.Write(s.counter[:])
+	s.sum = s.mac.Sum(s.sum[:0])
+	value, err := Truncate(s.sum)
+	if err != nil {
+		return "", err
+	}
+	return g.encoder(value, g.length)
+}
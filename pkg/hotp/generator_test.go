@@ -0,0 +1,143 @@
+package hotp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"sync"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	for _, tcase := range []struct {
+		name    string
+		hash    func() hash.Hash
+		length  uint
+		encoder Encoder
+	}{
+		{"SHA1", sha1.New, 6, nil},
+		{"SHA1-1", sha1.New, 1, nil},
+		{"SHA256", sha256.New, 8, Decimal},
+		{"SHA512", sha512.New, 9, nil},
+		{"Steam", sha1.New, 5, Steam},
+	} {
+		g, err := NewGenerator(tcase.hash, secret, tcase.length, tcase.encoder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		window, err := g.GenerateWindow(nil, 0, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for c := uint64(0); c < 1000; c++ {
+			expected, err := GenerateHOTPEncoded(tcase.hash, secret, c, tcase.length, tcase.encoder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, err := g.Generate(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != expected || window[c] != expected {
+				t.Fatalf("%s counter %d: expected %s got %s and %s", tcase.name, c, expected, code, window[c])
+			}
+		}
+	}
+}
+
+func TestGeneratorInvalid(t *testing.T) {
+	for _, tcase := range []struct {
+		hash   func() hash.Hash
+		length uint
+		err    error
+	}{
+		{nil, 6, ErrInvalidHash},
+		{md5.New, 6, ErrInvalidHash},
+		{sha1.New, 0, ErrInvalidDigits},
+		{sha1.New, 10, ErrInvalidDigits},
+	} {
+		if _, err := NewGenerator(tcase.hash, []byte("12345678901234567890"), tcase.length, nil); err != tcase.err {
+			t.Errorf("expected %v got %v", tcase.err, err)
+		}
+	}
+}
+
+func TestGeneratorConcurrent(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	g, err := NewGenerator(sha1.New, secret, 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(offset uint64) {
+			defer wg.Done()
+			for c := offset; c < offset+200; c++ {
+				code, err := g.Generate(c)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if expected, _ := GenerateHOTP(sha1.New, secret, c, 6); code != expected {
+					t.Errorf("counter %d: expected %s got %s", c, expected, code)
+					return
+				}
+			}
+		}(uint64(i) * 100)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestGeneratorAllocs(t *testing.T) {
+	g, err := NewGenerator(sha1.New, []byte("12345678901234567890"), 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// borrow the state directly, as the race detector makes sync.Pool drop items at random
+	s := g.states.Get().(*generatorState)
+	c := uint64(0)
+	// only the returned code is allocated
+	if n := testing.AllocsPerRun(100, func() {
+		c++
+		if _, err := g.generate(s, c); err != nil {
+			t.Fatal(err)
+		}
+	}); n > 1 {
+		t.Errorf("expected at most 1 allocation got %v", n)
+	}
+}
+
+func BenchmarkGenerateHOTP(b *testing.B) {
+	secret := []byte("12345678901234567890")
+	for i := 0; i < b.N; i++ {
+		if _, err := GenerateHOTP(sha1.New, secret, uint64(i), 6); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerator(b *testing.B) {
+	g, err := NewGenerator(sha1.New, []byte("12345678901234567890"), 6, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		c := uint64(0)
+		for pb.Next() {
+			c++
+			if _, err := g.Generate(c); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -3,21 +3,103 @@ package hotp
 import (
 	"crypto/hmac"
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"hash"
-	"math"
 )
 
+var (
+	// ErrInvalidHash is returned when no hash function is provided, or it produces fewer than the 20 bytes required
+	// for dynamic truncation, e.g. MD5
+	ErrInvalidHash = errors.New("invalid hash")
+	// ErrInvalidDigits is returned when the number of digits is not between 1 and 9, as the 31-bit truncated value has
+	// at most 10 decimal digits and the first of those is biased
+	ErrInvalidDigits = errors.New("digits must be between 1 and 9")
+)
+
+// steamAlphabet is the alphabet of Steam Guard codes
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// pow10 holds the powers of 10 up to 10^9 for Decimal
+var pow10 = [...]uint32{1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000, 1000000000}
+
+// Encoder renders the 31-bit value produced by dynamic truncation as a code of length symbols
+type Encoder func(value uint32, length uint) (string, error)
+
+// Decimal is the Encoder of RFC 4226, rendering value mod 10^length as length decimal digits
+func Decimal(value uint32, length uint) (string, error) {
+	if length < 1 || length > 9 {
+		return "", ErrInvalidDigits
+	}
+	/*
+			Step 3: Compute an HOTP value
+		   	Let Snum  = StToNum(Sbits)   // Convert S to a number in
+		                                    0...2^{31}-1
+		   	Return D = Snum mod 10^Digit //  D is a number in the range
+		                                    0...10^{Digit}-1
+	*/
+	d := value % pow10[length]
+	// render with leading zeros
+	var code [9]byte
+	for i := int(length) - 1; i >= 0; i-- {
+		code[i] = byte('0' + d%10)
+		d /= 10
+	}
+	return string(code[:length]), nil
+}
+
+// Steam is the Encoder of Steam Guard, rendering value as length symbols from a 26 symbol alphabet, least significant
+// first. Steam Guard codes have 5 symbols.
+func Steam(value uint32, length uint) (string, error) {
+	if length < 1 || length > 9 {
+		return "", ErrInvalidDigits
+	}
+	code := make([]byte, length)
+	for i := range code {
+		code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+		value /= uint32(len(steamAlphabet))
+	}
+	return string(code), nil
+}
+
 // GenerateHOTP generates a HMAC-Based One-Time Password Algorithm
-func GenerateHOTP(hash func() hash.Hash, secret []byte, counter uint64, length uint) string {
-	countBytes := make([]byte, 8)
+func GenerateHOTP(hash func() hash.Hash, secret []byte, counter uint64, length uint) (string, error) {
+	if hash == nil {
+		return "", ErrInvalidHash
+	}
+	if length < 1 || length > 9 {
+		return "", ErrInvalidDigits
+	}
+	return GenerateHOTPEncoded(hash, secret, counter, length, Decimal)
+}
+
+// GenerateHOTPEncoded generates a HMAC-Based One-Time Password Algorithm with the truncated value rendered by encoder
+// in place of decimal digits, e.g. Steam. Decimal is used when encoder is nil.
+func GenerateHOTPEncoded(hash func() hash.Hash, secret []byte, counter uint64, length uint, encoder Encoder) (string, error) {
+	if hash == nil {
+		return "", ErrInvalidHash
+	}
+	if encoder == nil {
+		encoder = Decimal
+	}
+	var countBytes [8]byte
 	/*
 		Step 1: Generate an HMAC-SHA-1 value Let HS = HMAC-SHA-1(K,C)  // HS is a 20-byte string
 	*/
 	h := hmac.New(hash, secret)
-	binary.BigEndian.PutUint64(countBytes, counter)
-	h.Write(countBytes)
-	bytes := h.Sum(nil)
+	binary.BigEndian.PutUint64(countBytes[:], counter)
+	h.Write(countBytes[:])
+	value, err := Truncate(h.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	return encoder(value, length)
+}
+
+// Truncate performs the dynamic truncation of RFC 4226 section 5.3 on a HMAC value, returning a 31-bit value
+func Truncate(bytes []byte) (uint32, error) {
+	if len(bytes) < 20 {
+		return 0, ErrInvalidHash
+	}
 	/*
 			Step 2: Generate a 4-byte string (Dynamic Truncation)
 			Let Sbits = DT(HS)   //  DT, defined below,
@@ -28,20 +110,6 @@ func GenerateHOTP(hash func() hash.Hash, secret []byte, counter uint64, length u
 		     Let P = String[OffSet]...String[OffSet+3]
 		     Return the Last 31 bits of P
 	*/
-
 	offsetBits := bytes[len(bytes)-1] & 0xf
-	Snum := (int(bytes[offsetBits])&0x7f)<<24 |
-		(int(bytes[offsetBits+1]&0xff))<<16 |
-		(int(bytes[offsetBits+2]&0xff))<<8 |
-		int(bytes[offsetBits+3])&0xff
-
-	/*
-			Step 3: Compute an HOTP value
-		   	Let Snum  = StToNum(Sbits)   // Convert S to a number in
-		                                    0...2^{31}-1
-		   	Return D = Snum mod 10^Digit //  D is a number in the range
-		                                    0...10^{Digit}-1
-	*/
-	// thanks https://stackoverflow.com/a/51546906 did not know about *
-	return fmt.Sprintf("%0*d", int(length), int64(Snum)%int64(math.Pow10(int(length))))
+	return binary.BigEndian.Uint32(bytes[offsetBits:offsetBits+4]) & 0x7fffffff, nil
 }
@@ -1,8 +1,10 @@
 package hotp
 
 import (
+	"crypto/md5"
 	"crypto/sha1"
 	"hash"
+	"hash/crc64"
 	"testing"
 )
 
@@ -62,9 +64,77 @@ func TestGenerateHOTP(t *testing.T) {
 		{8, "399871"},
 		{9, "520489"},
 	} {
-		v := GenerateHOTP(h, secret, uint64(tcase.c), 6)
+		v, err := GenerateHOTP(h, secret, uint64(tcase.c), 6)
+		if err != nil {
+			t.Error(err)
+		}
 		if v != tcase.htop {
 			t.Errorf("%s != %s", v, tcase.htop)
 		}
 	}
 }
+
+func TestGenerateHOTPInvalid(t *testing.T) {
+	h := func() hash.Hash { return sha1.New() }
+	secret := []byte("12345678901234567890")
+	for _, tcase := range []struct {
+		hash   func() hash.Hash
+		length uint
+		err    error
+	}{
+		{nil, 6, ErrInvalidHash},
+		{func() hash.Hash { return crc64.New(crc64.MakeTable(crc64.ISO)) }, 6, ErrInvalidHash},
+		{h, 0, ErrInvalidDigits},
+		{h, 10, ErrInvalidDigits},
+	} {
+		if _, err := GenerateHOTP(tcase.hash, secret, 0, tcase.length); err != tcase.err {
+			t.Errorf("expected %v got %v", tcase.err, err)
+		}
+	}
+}
+
+func TestGenerateHOTPEncodedSteam(t *testing.T) {
+	h := func() hash.Hash { return sha1.New() }
+	// test vectors from the ValvePython steam library, for Unix times 3000029 and 3000030 with a 30 second period
+	for _, tcase := range []struct {
+		counter uint64
+		code    string
+	}{
+		{100000, "94R9D"},
+		{100001, "YRGQJ"},
+	} {
+		code, err := GenerateHOTPEncoded(h, []byte("superdupersecret"), tcase.counter, 5, Steam)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tcase.code {
+			t.Errorf("expected %s got %s", tcase.code, code)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	// RFC 4226 appendix D, count 1
+	b := []byte{0x75, 0xa4, 0x8a, 0x19, 0xd4, 0xcb, 0xe1, 0x00, 0x64, 0x4e, 0x8a, 0xc1, 0x39, 0x7e, 0xea, 0x74, 0x7a, 0x2d, 0x33, 0xab}
+	v, err := Truncate(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 0x41397eea {
+		t.Errorf("expected %x got %x", 0x41397eea, v)
+	}
+	// dynamic truncation is only defined for at least 20 bytes
+	for _, n := range []int{16, 19} {
+		if _, err := Truncate(b[:n]); err != ErrInvalidHash {
+			t.Errorf("%d bytes: expected %v got %v", n, ErrInvalidHash, err)
+		}
+	}
+}
+
+func TestGenerateHOTPMD5(t *testing.T) {
+	h := func() hash.Hash { return md5.New() }
+	// the 16 byte MD5 HMAC is too short for dynamic truncation
+	if _, err := GenerateHOTP(h, []byte("12345678901234567890"), 0, 6); err != ErrInvalidHash {
+		t.Errorf("expected %v got %v", ErrInvalidHash, err)
+	}
+}
@@ -0,0 +1,96 @@
+package hotp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"math"
+)
+
+var (
+	// ErrInvalidCode is returned when a code does not match any HOTP within the look-ahead window
+	ErrInvalidCode = errors.New("invalid code")
+	// ErrInvalidLookAhead is returned when the look-ahead window extends beyond the largest counter value
+	ErrInvalidLookAhead = errors.New("look-ahead window exceeds the counter range")
+)
+
+// VerifyHOTP checks code against the HOTPs for counter and the lookAhead counter values following it as described in
+// RFC 4226 section 7.4. On success next is the counter value following the matching counter, which should be stored
+// and used for the next verification. Every counter in the window is compared in constant time.
+func VerifyHOTP(hash func() hash.Hash, secret []byte, code string, counter uint64, length uint, lookAhead uint) (next uint64, err error) {
+	g, err := NewGenerator(hash, secret, length, nil)
+	if err != nil {
+		return counter, err
+	}
+	return g.Verify(code, counter, lookAhead)
+}
+
+// ResyncHOTP resynchronises a token which has fallen further behind than the usual look-ahead window allows, using two
+// consecutive codes provided by the user as described in RFC 4226 appendix E.4. The counters from counter up to
+// counter+lookAhead are searched for one producing code1 which is immediately followed by code2. On success next is
+// the counter value following the counter which produced code2.
+func ResyncHOTP(hash func() hash.Hash, secret []byte, code1 string, code2 string, counter uint64, length uint, lookAhead uint) (next uint64, err error) {
+	g, err := NewGenerator(hash, secret, length, nil)
+	if err != nil {
+		return counter, err
+	}
+	return g.Resync(code1, code2, counter, lookAhead)
+}
+
+// Verify checks code as VerifyHOTP does using the codes of the Generator, which may be kept and reused for every
+// verification of the same secret
+func (g *Generator) Verify(code string, counter uint64, lookAhead uint) (next uint64, err error) {
+	// next must not overflow
+	if counter > math.MaxUint64-1 || uint64(lookAhead) > math.MaxUint64-1-counter {
+		return counter, ErrInvalidLookAhead
+	}
+	s := g.states.Get().(*generatorState)
+	defer g.states.Put(s)
+	found := false
+	for i := uint64(0); i <= uint64(lookAhead); i++ {
+		c, err := g.generate(s, counter+i)
+		if err != nil {
+			return counter, err
+		}
+		if subtle.ConstantTimeCompare([]byte(c), []byte(code)) == 1 && !found {
+			found = true
+			next = counter + i + 1
+		}
+	}
+	if !found {
+		return counter, ErrInvalidCode
+	}
+	return next, nil
+}
+
+// Resync resynchronises a token as ResyncHOTP does using the codes of the Generator
+func (g *Generator) Resync(code1 string, code2 string, counter uint64, lookAhead uint) (next uint64, err error) {
+	// next must not overflow
+	if counter > math.MaxUint64-2 || uint64(lookAhead) > math.MaxUint64-2-counter {
+		return counter, ErrInvalidLookAhead
+	}
+	s := g.states.Get().(*generatorState)
+	defer g.states.Put(s)
+	prev, err := g.generate(s, counter)
+	if err != nil {
+		return counter, err
+	}
+	found := false
+	for i := uint64(0); i <= uint64(lookAhead); i++ {
+		c, err := g.generate(s, counter+i+1)
+		if err != nil {
+			return counter, err
+		}
+		m1 := subtle.ConstantTimeCompare([]byte(prev), []byte(code1))
+		m2 := subtle.ConstantTimeCompare([]byte(c), []byte(code2))
+		if m1&m2 == 1 && !found {
+			found = true
+			next = counter + i + 2
+		}
+		prev = c
+	}
+	if !found {
+		return counter, ErrInvalidCode
+	}
+	return next, nil
+}
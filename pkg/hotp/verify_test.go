@@ -0,0 +1,92 @@
+package hotp
+
+import (
+	"crypto/sha1"
+	"hash"
+	"math"
+	"testing"
+)
+
+func TestVerifyHOTP(t *testing.T) {
+	h := func() hash.Hash { return sha1.New() }
+	secret := []byte("12345678901234567890")
+	for _, tcase := range []struct {
+		code      string
+		counter   uint64
+		lookAhead uint
+		next      uint64
+		err       error
+	}{
+		{"755224", 0, 0, 1, nil},
+		{"969429", 0, 3, 4, nil},
+		{"338314", 0, 3, 0, ErrInvalidCode},
+		{"755224", 1, 5, 1, ErrInvalidCode},
+	} {
+		next, err := VerifyHOTP(h, secret, tcase.code, tcase.counter, 6, tcase.lookAhead)
+		if err != tcase.err {
+			t.Errorf("expected error %v got %v", tcase.err, err)
+		}
+		if next != tcase.next {
+			t.Errorf("expected next %d got %d", tcase.next, next)
+		}
+	}
+}
+
+func TestResyncHOTP(t *testing.T) {
+	h := func() hash.Hash { return sha1.New() }
+	secret := []byte("12345678901234567890")
+	for _, tcase := range []struct {
+		code1   string
+		code2   string
+		counter uint64
+		next    uint64
+		err     error
+	}{
+		{"162583", "399871", 0, 9, nil},
+		{"755224", "287082", 0, 2, nil},
+		{"162583", "520489", 0, 0, ErrInvalidCode},
+		{"399871", "162583", 0, 0, ErrInvalidCode},
+	} {
+		next, err := ResyncHOTP(h, secret, tcase.code1, tcase.code2, tcase.counter, 6, 20)
+		if err != tcase.err {
+			t.Errorf("expected error %v got %v", tcase.err, err)
+		}
+		if next != tcase.next {
+			t.Errorf("expected next %d got %d", tcase.next, next)
+		}
+	}
+}
+
+func TestVerifyHOTPLookAheadOverflow(t *testing.T) {
+	h := func() hash.Hash { return sha1.New() }
+	secret := []byte("12345678901234567890")
+	if _, err := VerifyHOTP(h, secret, "755224", math.MaxUint64, 6, 0); err != ErrInvalidLookAhead {
+		t.Errorf("expected %v got %v", ErrInvalidLookAhead, err)
+	}
+	if _, err := VerifyHOTP(h, secret, "755224", math.MaxUint64-5, 6, 5); err != ErrInvalidLookAhead {
+		t.Errorf("expected %v got %v", ErrInvalidLookAhead, err)
+	}
+	if _, err := VerifyHOTP(h, secret, "755224", math.MaxUint64-5, 6, 4); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+	if _, err := ResyncHOTP(h, secret, "755224", "287082", math.MaxUint64-1, 6, 0); err != ErrInvalidLookAhead {
+		t.Errorf("expected %v got %v", ErrInvalidLookAhead, err)
+	}
+}
+
+func TestGeneratorVerify(t *testing.T) {
+	g, err := NewGenerator(sha1.New, []byte("12345678901234567890"), 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same Generator is reused for each verification
+	counter := uint64(0)
+	for _, code := range []string{"755224", "287082", "359152"} {
+		if counter, err = g.Verify(code, counter, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if next, err := g.Resync("399871", "520489", counter, 10); err != nil || next != 10 {
+		t.Errorf("expected 10 got %d %v", next, err)
+	}
+}
@@ -0,0 +1,308 @@
+package ocra
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/hotp"
+	"github.com/richardjennings/totp/pkg/totp"
+	"hash"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// challengeSize is the number of bytes the challenge is padded to in the data input
+	challengeSize = 128
+	// defaultSessionLength is the number of bytes of session information for a suite with S and no length
+	defaultSessionLength = 64
+)
+
+var (
+	// ErrInvalidSuite is returned when an OCRA suite string cannot be parsed
+	ErrInvalidSuite = errors.New("invalid ocra suite")
+	// ErrInvalidChallenge is returned when a challenge does not match the format and length of the suite
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	// ErrInvalidSession is returned when session information is longer than the suite allows
+	ErrInvalidSession = errors.New("invalid session information")
+	// ErrInvalidResponse is returned when a response does not match
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+type (
+	// Suite is a parsed OCRA suite, which has the format OCRA-1:CryptoFunction:DataInput as in RFC 6287 section 6,
+	// e.g. OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1
+	Suite struct {
+		// the suite string, which is part of the data input
+		name string
+		// the HMAC hash of the crypto function
+		Algorithm totp.Algo
+		// the number of digits of a response
+		Digits uint
+		// whether the data input includes a counter
+		Counter bool
+		// the challenge format: A for alphanumeric, N for numeric or H for hexadecimal
+		ChallengeFormat byte
+		// the length of a challenge, between 4 and 64
+		ChallengeLength int
+		// the hash of the PIN in the data input, or totp.Invalid when there is no PIN
+		PIN totp.Algo
+		// the number of bytes of session information in the data input, or 0 when there is none
+		SessionLength int
+		// the time step of the timestamp in the data input, or 0 when there is no timestamp
+		Timestep time.Duration
+	}
+
+	// Input is the data input to an OCRA computation. Only the fields required by the suite are used.
+	Input struct {
+		// the counter, synchronised between the client and server
+		Counter uint64
+		// the challenge, in the format of the suite
+		Challenge string
+		// the PIN, which is hashed with the PIN hash of the suite
+		PIN string
+		// session information, left padded with zeros to the session length of the suite
+		Session []byte
+		// provides the current time for the timestamp. The system time is used when nil
+		Clock totp.Clock
+	}
+)
+
+// ParseSuite parses an OCRA suite string. A truncation length of 0, meaning the full HMAC value, is not supported.
+func ParseSuite(suite string) (s Suite, err error) {
+	parts := strings.Split(suite, ":")
+	if len(parts) != 3 || parts[0] != "OCRA-1" {
+		return s, fmt.Errorf("%w %s", ErrInvalidSuite, suite)
+	}
+	s.name = suite
+
+	// CryptoFunction is HOTP-H-t
+	f := strings.Split(parts[1], "-")
+	if len(f) != 3 || f[0] != "HOTP" {
+		return s, fmt.Errorf("%w crypto function %s", ErrInvalidSuite, parts[1])
+	}
+	if s.Algorithm, err = totp.ParseAlgorithm(f[1]); err != nil {
+		return s, fmt.Errorf("%w crypto function %s", ErrInvalidSuite, parts[1])
+	}
+	digits, err := strconv.Atoi(f[2])
+	if err != nil || digits < 4 || digits > 10 {
+		return s, fmt.Errorf("%w truncation %s", ErrInvalidSuite, f[2])
+	}
+	s.Digits = uint(digits)
+
+	// DataInput is [C] | QFxx | [PH | Snnn | TG]
+	d := strings.Split(parts[2], "-")
+	if d[0] == "C" {
+		s.Counter = true
+		d = d[1:]
+	}
+	if len(d) == 0 || len(d[0]) != 4 || d[0][0] != 'Q' || !strings.ContainsRune("ANH", rune(d[0][1])) {
+		return s, fmt.Errorf("%w challenge in %s", ErrInvalidSuite, parts[2])
+	}
+	s.ChallengeFormat = d[0][1]
+	s.ChallengeLength, err = strconv.Atoi(d[0][2:])
+	if err != nil || s.ChallengeLength < 4 || s.ChallengeLength > 64 {
+		return s, fmt.Errorf("%w challenge length %s", ErrInvalidSuite, d[0])
+	}
+	for _, v := range d[1:] {
+		switch {
+		case strings.HasPrefix(v, "P") && s.PIN == totp.Invalid && s.SessionLength == 0 && s.Timestep == 0:
+			if s.PIN, err = totp.ParseAlgorithm(v[1:]); err != nil {
+				return s, fmt.Errorf("%w pin hash %s", ErrInvalidSuite, v)
+			}
+		case strings.HasPrefix(v, "S") && s.SessionLength == 0 && s.Timestep == 0:
+			s.SessionLength = defaultSessionLength
+			if len(v) > 1 {
+				if len(v) != 4 {
+					return s, fmt.Errorf("%w session length %s", ErrInvalidSuite, v)
+				}
+				if s.SessionLength, err = strconv.Atoi(v[1:]); err != nil || s.SessionLength < 1 {
+					return s, fmt.Errorf("%w session length %s", ErrInvalidSuite, v)
+				}
+			}
+		case strings.HasPrefix(v, "T") && s.Timestep == 0:
+			if s.Timestep, err = parseTimestep(v[1:]); err != nil {
+				return s, err
+			}
+		default:
+			return s, fmt.Errorf("%w data input %s", ErrInvalidSuite, v)
+		}
+	}
+	return s, nil
+}
+
+// hashOf returns the hash function of a, which may have been disabled in the registry since the suite was parsed
+func hashOf(a totp.Algo) (func() hash.Hash, error) {
+	h := a.Hash()
+	if h == nil {
+		return nil, fmt.Errorf("%w algorithm %s is not enabled", ErrInvalidSuite, a)
+	}
+	return h, nil
+}
+
+// parseTimestep parses a time step of the form [1-59]S, [1-59]M or [1-48]H. The default is 1M
+func parseTimestep(g string) (time.Duration, error) {
+	if g == "" {
+		return time.Minute, nil
+	}
+	n, err := strconv.Atoi(g[:len(g)-1])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w time step %s", ErrInvalidSuite, g)
+	}
+	switch g[len(g)-1] {
+	case 'S':
+		if n <= 59 {
+			return time.Duration(n) * time.Second, nil
+		}
+	case 'M':
+		if n <= 59 {
+			return time.Duration(n) * time.Minute, nil
+		}
+	case 'H':
+		if n <= 48 {
+			return time.Duration(n) * time.Hour, nil
+		}
+	}
+	return 0, fmt.Errorf("%w time step %s", ErrInvalidSuite, g)
+}
+
+// String returns the suite string
+func (s Suite) String() string {
+	return s.name
+}
+
+// DataInput returns the message which is signed with HMAC to compute a response, as in RFC 6287 section 5.1
+func (s Suite) DataInput(in Input) ([]byte, error) {
+	b := append([]byte(s.name), 0)
+	if s.Counter {
+		b = appendUint64(b, in.Counter)
+	}
+	q, err := s.challenge(in.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, q...)
+	if s.PIN != totp.Invalid {
+		p, err := hashOf(s.PIN)
+		if err != nil {
+			return nil, err
+		}
+		h := p()
+		h.Write([]byte(in.PIN))
+		b = h.Sum(b)
+	}
+	if s.SessionLength > 0 {
+		if len(in.Session) > s.SessionLength {
+			return nil, ErrInvalidSession
+		}
+		b = append(b, make([]byte, s.SessionLength-len(in.Session))...)
+		b = append(b, in.Session...)
+	}
+	if s.Timestep > 0 {
+		t := time.Now()
+		if in.Clock != nil {
+			t = in.Clock.Now()
+		}
+		b = appendUint64(b, uint64(t.Unix())/uint64(s.Timestep/time.Second))
+	}
+	return b, nil
+}
+
+// appendUint64 appends v to b as 8 bytes big endian
+func appendUint64(b []byte, v uint64) []byte {
+	var u [8]byte
+	binary.BigEndian.PutUint64(u[:], v)
+	return append(b, u[:]...)
+}
+
+// challenge returns the challenge encoded and padded for the data input. Challenges longer than ChallengeLength are
+// accepted as mutual challenge response in RFC 6287 section 7.3 concatenates the client and server challenges.
+func (s Suite) challenge(q string) ([]byte, error) {
+	if len(q) < 4 {
+		return nil, ErrInvalidChallenge
+	}
+	var h string
+	switch s.ChallengeFormat {
+	case 'A':
+		if len(q) > challengeSize {
+			return nil, ErrInvalidChallenge
+		}
+		b := make([]byte, challengeSize)
+		copy(b, q)
+		return b, nil
+	case 'N':
+		n, ok := new(big.Int).SetString(q, 10)
+		if !ok || n.Sign() < 0 {
+			return nil, ErrInvalidChallenge
+		}
+		h = n.Text(16)
+	case 'H':
+		h = q
+	}
+	if len(h) > 2*challengeSize {
+		return nil, ErrInvalidChallenge
+	}
+	// the hexadecimal challenge is padded on the right, so an odd length leaves the last byte half filled
+	b, err := hex.DecodeString(h + strings.Repeat("0", 2*challengeSize-len(h)))
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	return b, nil
+}
+
+// Generate computes the response of the suite to in with key
+func (s Suite) Generate(key []byte, in Input) (string, error) {
+	d, err := s.DataInput(in)
+	if err != nil {
+		return "", err
+	}
+	a, err := hashOf(s.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	h := hmac.New(a, key)
+	h.Write(d)
+	value, err := hotp.Truncate(h.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	mod := uint64(1)
+	for i := uint(0); i < s.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", int(s.Digits), uint64(value)%mod), nil
+}
+
+// Verify checks response against the response of the suite to in with key, comparing in constant time. For suites
+// with a timestamp the time steps within window of the current time step are checked, and offset is the position of
+// the matching time step relative to the current time step. The window is ignored for suites without a timestamp.
+func (s Suite) Verify(key []byte, in Input, response string, window totp.Window) (offset int, err error) {
+	if s.Timestep == 0 {
+		window = totp.Window{}
+	}
+	t := time.Now()
+	if in.Clock != nil {
+		t = in.Clock.Now()
+	}
+	found := false
+	for i := -int(window.Behind); i <= int(window.Ahead); i++ {
+		in.Clock = totp.FixedClock{Time: t.Add(time.Duration(i) * s.Timestep)}
+		r, err := s.Generate(key, in)
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(r), []byte(response)) == 1 && !found {
+			found = true
+			offset = i
+		}
+	}
+	if !found {
+		return 0, ErrInvalidResponse
+	}
+	return offset, nil
+}
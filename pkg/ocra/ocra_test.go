@@ -0,0 +1,233 @@
+package ocra
+
+import (
+	"errors"
+	"github.com/richardjennings/totp/pkg/totp"
+	"testing"
+	"time"
+)
+
+// keys and PIN of the RFC 6287 appendix C test vectors
+var (
+	key20 = []byte("12345678901234567890")
+	key32 = []byte("12345678901234567890123456789012")
+	key64 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+	pin   = "1234"
+	// the timestamp of the test vectors, 0x132d0b6 minutes since the Unix epoch
+	timestamp = totp.FixedClock{Time: time.Unix(0x132d0b6*60, 0)}
+)
+
+func TestGenerate(t *testing.T) {
+	for _, tcase := range []struct {
+		suite    string
+		key      []byte
+		input    Input
+		response string
+	}{
+		// C.1 one-way challenge response
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "00000000"}, "237653"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "11111111"}, "243178"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "22222222"}, "653583"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "33333333"}, "740991"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "44444444"}, "608993"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "55555555"}, "388898"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "66666666"}, "816933"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "77777777"}, "224598"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "88888888"}, "750600"},
+		{"OCRA-1:HOTP-SHA1-6:QN08", key20, Input{Challenge: "99999999"}, "294470"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 0, Challenge: "12345678", PIN: pin}, "65347737"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 1, Challenge: "12345678", PIN: pin}, "86775851"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 2, Challenge: "12345678", PIN: pin}, "78192410"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 3, Challenge: "12345678", PIN: pin}, "71565254"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 4, Challenge: "12345678", PIN: pin}, "10104329"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 5, Challenge: "12345678", PIN: pin}, "65983500"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 6, Challenge: "12345678", PIN: pin}, "70069104"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 7, Challenge: "12345678", PIN: pin}, "91771096"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 8, Challenge: "12345678", PIN: pin}, "75011558"},
+		{"OCRA-1:HOTP-SHA256-8:C-QN08-PSHA1", key32, Input{Counter: 9, Challenge: "12345678", PIN: pin}, "08522129"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", key32, Input{Challenge: "00000000", PIN: pin}, "83238735"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", key32, Input{Challenge: "11111111", PIN: pin}, "01501458"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", key32, Input{Challenge: "22222222", PIN: pin}, "17957585"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", key32, Input{Challenge: "33333333", PIN: pin}, "86776967"},
+		{"OCRA-1:HOTP-SHA256-8:QN08-PSHA1", key32, Input{Challenge: "44444444", PIN: pin}, "86807031"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 0, Challenge: "00000000"}, "07016083"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 1, Challenge: "11111111"}, "63947962"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 2, Challenge: "22222222"}, "70123924"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 3, Challenge: "33333333"}, "25341727"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 4, Challenge: "44444444"}, "33203315"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 5, Challenge: "55555555"}, "34205738"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 6, Challenge: "66666666"}, "44343969"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 7, Challenge: "77777777"}, "51946085"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 8, Challenge: "88888888"}, "20403879"},
+		{"OCRA-1:HOTP-SHA512-8:C-QN08", key64, Input{Counter: 9, Challenge: "99999999"}, "31409299"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", key64, Input{Challenge: "00000000", Clock: timestamp}, "95209754"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", key64, Input{Challenge: "11111111", Clock: timestamp}, "55907591"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", key64, Input{Challenge: "22222222", Clock: timestamp}, "22048402"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", key64, Input{Challenge: "33333333", Clock: timestamp}, "24218844"},
+		{"OCRA-1:HOTP-SHA512-8:QN08-T1M", key64, Input{Challenge: "44444444", Clock: timestamp}, "36209546"},
+		// C.2 mutual challenge response
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "CLI22220SRV11110"}, "28247970"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "CLI22221SRV11111"}, "01984843"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "CLI22222SRV11112"}, "65387857"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "CLI22223SRV11113"}, "03351211"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "CLI22224SRV11114"}, "83412541"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SRV11110CLI22220"}, "15510767"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SRV11111CLI22221"}, "90175646"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SRV11112CLI22222"}, "33777207"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SRV11113CLI22223"}, "95285278"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SRV11114CLI22224"}, "28934924"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", key64, Input{Challenge: "CLI22220SRV11110"}, "79496648"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", key64, Input{Challenge: "CLI22221SRV11111"}, "76831980"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", key64, Input{Challenge: "CLI22222SRV11112"}, "12250499"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", key64, Input{Challenge: "CLI22223SRV11113"}, "90856481"},
+		{"OCRA-1:HOTP-SHA512-8:QA08", key64, Input{Challenge: "CLI22224SRV11114"}, "12761449"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", key64, Input{Challenge: "SRV11110CLI22220", PIN: pin}, "18806276"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", key64, Input{Challenge: "SRV11111CLI22221", PIN: pin}, "70020315"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", key64, Input{Challenge: "SRV11112CLI22222", PIN: pin}, "01600026"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", key64, Input{Challenge: "SRV11113CLI22223", PIN: pin}, "18951020"},
+		{"OCRA-1:HOTP-SHA512-8:QA08-PSHA1", key64, Input{Challenge: "SRV11114CLI22224", PIN: pin}, "32528969"},
+		// C.3 plain signature
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SIG10000"}, "53095496"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SIG11000"}, "04110475"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SIG12000"}, "31331128"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SIG13000"}, "76028668"},
+		{"OCRA-1:HOTP-SHA256-8:QA08", key32, Input{Challenge: "SIG14000"}, "46554205"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", key64, Input{Challenge: "SIG1000000", Clock: timestamp}, "77537423"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", key64, Input{Challenge: "SIG1100000", Clock: timestamp}, "31970405"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", key64, Input{Challenge: "SIG1200000", Clock: timestamp}, "10235557"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", key64, Input{Challenge: "SIG1300000", Clock: timestamp}, "95213541"},
+		{"OCRA-1:HOTP-SHA512-8:QA10-T1M", key64, Input{Challenge: "SIG1400000", Clock: timestamp}, "65360607"},
+	} {
+		s, err := ParseSuite(tcase.suite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := s.Generate(tcase.key, tcase.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != tcase.response {
+			t.Errorf("%s %s: expected %s got %s", tcase.suite, tcase.input.Challenge, tcase.response, r)
+		}
+	}
+}
+
+func TestParseSuite(t *testing.T) {
+	s, err := ParseSuite("OCRA-1:HOTP-SHA256-8:C-QH40-PSHA512-S128-T30S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Suite{
+		name:            "OCRA-1:HOTP-SHA256-8:C-QH40-PSHA512-S128-T30S",
+		Algorithm:       totp.SHA256,
+		Digits:          8,
+		Counter:         true,
+		ChallengeFormat: 'H',
+		ChallengeLength: 40,
+		PIN:             totp.SHA512,
+		SessionLength:   128,
+		Timestep:        30 * time.Second,
+	}
+	if s != expected {
+		t.Errorf("expected %+v got %+v", expected, s)
+	}
+	if s, err := ParseSuite("OCRA-1:HOTP-SHA1-6:QA64-S-T"); err != nil || s.SessionLength != 64 || s.Timestep != time.Minute {
+		t.Errorf("expected default session length and time step got %+v %v", s, err)
+	}
+	for _, suite := range []string{
+		"OCRA-2:HOTP-SHA1-6:QN08",
+		"OCRA-1:HOTP-MD5-6:QN08",
+		"OCRA-1:HOTP-SHA1-0:QN08",
+		"OCRA-1:HOTP-SHA1-11:QN08",
+		"OCRA-1:HOTP-SHA1-6:C",
+		"OCRA-1:HOTP-SHA1-6:QX08",
+		"OCRA-1:HOTP-SHA1-6:QN03",
+		"OCRA-1:HOTP-SHA1-6:QN65",
+		"OCRA-1:HOTP-SHA1-6:QN08-PMD5",
+		"OCRA-1:HOTP-SHA1-6:QN08-T1M-PSHA1",
+		"OCRA-1:HOTP-SHA1-6:QN08-T60S",
+		"OCRA-1:HOTP-SHA1-6:QN08-T49H",
+		"OCRA-1:HOTP-SHA1-6:QN08-X",
+	} {
+		if _, err := ParseSuite(suite); !errors.Is(err, ErrInvalidSuite) {
+			t.Errorf("%s: expected %v got %v", suite, ErrInvalidSuite, err)
+		}
+	}
+}
+
+func TestDataInputSession(t *testing.T) {
+	s, err := ParseSuite("OCRA-1:HOTP-SHA1-6:QH08-S004")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DataInput(Input{Challenge: "abc", Session: []byte{1, 2}}); err != ErrInvalidChallenge {
+		t.Errorf("expected %v got %v", ErrInvalidChallenge, err)
+	}
+	d, err := s.DataInput(Input{Challenge: "abcde", Session: []byte{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d) != len(s.String())+1+challengeSize+4 {
+		t.Fatalf("unexpected data input length %d", len(d))
+	}
+	q := d[len(s.String())+1:]
+	if q[0] != 0xab || q[1] != 0xcd || q[2] != 0xe0 || q[3] != 0 {
+		t.Errorf("unexpected challenge %x", q[:4])
+	}
+	if session := d[len(d)-4:]; string(session) != string([]byte{0, 0, 1, 2}) {
+		t.Errorf("unexpected session %x", session)
+	}
+	if _, err := s.DataInput(Input{Challenge: "abcde", Session: []byte{1, 2, 3, 4, 5}}); err != ErrInvalidSession {
+		t.Errorf("expected %v got %v", ErrInvalidSession, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s, err := ParseSuite("OCRA-1:HOTP-SHA512-8:QN08-T1M")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := Input{Challenge: "00000000", Clock: totp.FixedClock{Time: timestamp.Time.Add(time.Minute)}}
+	window := totp.Window{Behind: 1}
+	offset, err := s.Verify(key64, in, "95209754", window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != -1 {
+		t.Errorf("expected offset -1 got %d", offset)
+	}
+	if _, err := s.Verify(key64, in, "95209754", totp.Window{}); err != ErrInvalidResponse {
+		t.Errorf("expected %v got %v", ErrInvalidResponse, err)
+	}
+	s, err = ParseSuite("OCRA-1:HOTP-SHA1-6:QN08")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Verify(key20, Input{Challenge: "00000000"}, "237653", window); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDisabledAlgorithm(t *testing.T) {
+	t.Cleanup(func() {
+		if err := totp.EnableAlgorithm("SHA256"); err != nil {
+			t.Error(err)
+		}
+	})
+	s, err := ParseSuite("OCRA-1:HOTP-SHA1-6:QN08-PSHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := totp.DisableAlgorithm("SHA256"); err != nil {
+		t.Fatal(err)
+	}
+	for _, suite := range []string{"OCRA-1:HOTP-SHA256-8:QN08", "OCRA-1:HOTP-SHA1-6:QN08-PSHA256"} {
+		if _, err := ParseSuite(suite); !errors.Is(err, ErrInvalidSuite) {
+			t.Errorf("%s: expected %v got %v", suite, ErrInvalidSuite, err)
+		}
+	}
+	// a suite parsed before the algorithm was disabled is rejected rather than panicking
+	if _, err := s.Generate(key20, Input{Challenge: "00000000", PIN: pin}); !errors.Is(err, ErrInvalidSuite) {
+		t.Errorf("expected %v got %v", ErrInvalidSuite, err)
+	}
+}
@@ -0,0 +1,128 @@
+package otpauth
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// MaxMigrationBatchSize is the largest batch size accepted by MigrationURIImport, bounding the number of links of a
+// single export that are tracked
+const MaxMigrationBatchSize = 1000
+
+type (
+	// MigrationBatch describes the links of a single otpauth-migration export sharing a batch id
+	MigrationBatch struct {
+		// the batch id shared by the links of the export
+		BatchId int32
+		// the number of links in the export
+		BatchSize int32
+		// batch indexes for which no link was provided
+		Missing []int32
+		// batch indexes for which more than one link was provided
+		Duplicate []int32
+	}
+
+	// MigrationImportSummary describes the result of assembling otpauth-migration links with MigrationURIImport
+	MigrationImportSummary struct {
+		// the number of links imported
+		Links int
+		// the exports the links belong to, in the order they were first seen
+		Batches []MigrationBatch
+		// the number of distinct accounts imported
+		Accounts int
+		// the number of accounts discarded as duplicates of an account already imported
+		DuplicateAccounts int
+	}
+)
+
+// Complete reports whether every link of every export was provided
+func (s MigrationImportSummary) Complete() bool {
+	for _, b := range s.Batches {
+		if len(b.Missing) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MigrationURIImport assembles the accounts of many otpauth-migration links, such as the several QR codes of a large
+// Google Authenticator export, into a single MigrationURI. Links are grouped by batch id and ordered by batch index,
+// and accounts appearing more than once are only included once. Missing and duplicate batch indexes are reported in
+// the summary rather than as an error so that the accounts which were provided can still be imported. Links with a batch
+// size above MaxMigrationBatchSize, or a batch index outside of the batch, are rejected.
+func MigrationURIImport(links []*url.URL) (m MigrationURI, summary MigrationImportSummary, err error) {
+	var order []int32
+	batches := map[int32]map[int32][]*MigrationPayload{}
+	sizes := map[int32]int32{}
+	for _, u := range links {
+		mp, err := migrationPayloadDecode(u)
+		if err != nil {
+			return nil, summary, err
+		}
+		// exports predating batching leave the batch size unset
+		size := mp.BatchSize
+		if size == 0 {
+			size = 1
+		}
+		if size < 0 || size > MaxMigrationBatchSize {
+			return nil, summary, fmt.Errorf("invalid batch size %d", mp.BatchSize)
+		}
+		if mp.BatchIndex < 0 || mp.BatchIndex >= size {
+			return nil, summary, fmt.Errorf("invalid batch index %d for batch size %d", mp.BatchIndex, size)
+		}
+		if _, ok := batches[mp.BatchId]; !ok {
+			order = append(order, mp.BatchId)
+			batches[mp.BatchId] = map[int32][]*MigrationPayload{}
+		}
+		batches[mp.BatchId][mp.BatchIndex] = append(batches[mp.BatchId][mp.BatchIndex], mp)
+		if mp.BatchSize > sizes[mp.BatchId] {
+			sizes[mp.BatchId] = mp.BatchSize
+		}
+		summary.Links++
+	}
+
+	seen := map[string]bool{}
+	for _, id := range order {
+		b := MigrationBatch{BatchId: id, BatchSize: sizes[id]}
+		// exports predating batching leave the batch size unset
+		if b.BatchSize == 0 {
+			b.BatchSize = 1
+		}
+		var indexes []int
+		for i, p := range batches[id] {
+			indexes = append(indexes, int(i))
+			if len(p) > 1 {
+				b.Duplicate = append(b.Duplicate, i)
+			}
+		}
+		sort.Ints(indexes)
+		sort.Slice(b.Duplicate, func(i, j int) bool { return b.Duplicate[i] < b.Duplicate[j] })
+		for i := int32(0); i < b.BatchSize; i++ {
+			if _, ok := batches[id][i]; !ok {
+				b.Missing = append(b.Missing, i)
+			}
+		}
+		for _, i := range indexes {
+			for _, mp := range batches[id][int32(i)] {
+				uris, err := migrationPayloadAuthURIs(mp)
+				if err != nil {
+					return nil, summary, err
+				}
+				for _, uri := range uris {
+					k := uri.URL().String()
+					if seen[k] {
+						summary.DuplicateAccounts++
+						continue
+					}
+					seen[k] = true
+					m = append(m, uri)
+				}
+			}
+		}
+		summary.Batches = append(summary.Batches, b)
+	}
+	summary.Accounts = len(m)
+
+	return m, summary, nil
+}
@@ -0,0 +1,81 @@
+package otpauth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMigrationURIImport(t *testing.T) {
+	var m MigrationURI
+	for i := 0; i < 25; i++ {
+		a, err := NewAuthURI(fmt.Sprintf("totp%d@myorg", i), "SHA1", 6, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m = append(m, a)
+	}
+	links, err := MigrationURIEncode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, summary, err := MigrationURIImport([]*url.URL{links[2], links[0], links[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Complete() || summary.Accounts != 25 || summary.DuplicateAccounts != 0 || len(summary.Batches) != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	for i := range m {
+		if imported[i].URL().String() != m[i].URL().String() {
+			t.Errorf("expected %s got %s", m[i].URL(), imported[i].URL())
+		}
+	}
+
+	_, summary, err = MigrationURIImport([]*url.URL{links[0], links[2], links[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Complete() {
+		t.Error("expected incomplete import")
+	}
+	if summary.Links != 3 || summary.Accounts != 15 || summary.DuplicateAccounts != 5 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	b := summary.Batches[0]
+	if b.BatchSize != 3 || !reflect.DeepEqual(b.Missing, []int32{1}) || !reflect.DeepEqual(b.Duplicate, []int32{2}) {
+		t.Errorf("unexpected batch %+v", b)
+	}
+}
+
+func TestMigrationURIImportInvalidBatch(t *testing.T) {
+	for _, tcase := range []struct {
+		size  int32
+		index int32
+	}{
+		{MaxMigrationBatchSize + 1, 0},
+		{-1, 0},
+		{3, 3},
+		{3, -1},
+		{0, 1},
+	} {
+		rs, err := proto.Marshal(&MigrationPayload{Version: 1, BatchSize: tcase.size, BatchIndex: tcase.index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := url.Values{}
+		q.Add("data", base64.StdEncoding.EncodeToString(rs))
+		u := &url.URL{Scheme: "otpauth-migration", Host: "offline", RawQuery: q.Encode()}
+		if _, _, err := MigrationURIImport([]*url.URL{u}); err == nil {
+			t.Errorf("expected error for batch index %d of %d", tcase.index, tcase.size)
+		} else if !strings.Contains(err.Error(), "invalid batch") {
+			t.Errorf("expected invalid batch error got %v", err)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package otpauth
 
 import (
+	"crypto/rand"
 	"encoding/base32"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/richardjennings/totp/pkg/hotp"
 	"github.com/richardjennings/totp/pkg/totp"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/protobuf/proto"
@@ -22,6 +26,8 @@ type (
 		Scheme string
 
 		// Valid types are hotp and totp, to distinguish whether the key will be used for counter-based HOTP or for TOTP.
+		// The steam type, used by authenticators such as Aegis, is TOTP rendered as Steam Guard codes, and the motp and
+		// yaotp types are Mobile-OTP and Yandex Key codes.
 		Type string
 
 		// label = accountname / issuer (“:” / “%3A”) *”%20” accountname
@@ -29,6 +35,7 @@ type (
 
 		// REQUIRED: The secret parameter is an arbitrary key value encoded in Base32 according to RFC 3548.
 		// The padding specified in RFC 3548 section 2.2 is not required and should be omitted.
+		// Secret holds the decoded key, EncodedSecret returns the Base32 form used in links.
 		Secret []byte
 
 		// STRONGLY RECOMMENDED: The issuer parameter is a string value indicating the provider or service this account is
@@ -37,7 +44,8 @@ type (
 		// If both issuer parameter and issuer label prefix are present, they should be equal.
 		Issuer string
 
-		// The algorithm may have the values: SHA1, SHA256, SHA512
+		// The algorithm may have the values: SHA1, SHA256, SHA512, and any other algorithm enabled in the totp
+		// algorithm registry, e.g. MD5
 		Algorithm totp.Algo
 
 		// The digits parameter may have the values 6 or 8, and determines how long of a one-time passcode to display to
@@ -50,11 +58,30 @@ type (
 
 		// The period parameter defines a period that a TOTP code will be valid for, in seconds. The default value is 30.
 		Period int
+
+		// The t0 parameter is the Unix time from which TOTP periods are counted, as in RFC 6238. The default value is
+		// 0, the Unix epoch. It is not part of the Key Uri Format and is only included in links when not 0.
+		InitialCounterTime uint64
+
+		// The PIN combined with the secret of motp and yaotp AuthURIs. It is read from the non-standard pin parameter
+		// when present, but is never included in links so that it is not stored or shown alongside the secret.
+		PIN string
 	}
 
 	MigrationURI []AuthURI
 )
 
+// MigrationBatchSize is the maximum number of accounts encoded in a single otpauth-migration link
+const MigrationBatchSize = 10
+
+// migrationAlgorithms maps the algorithms of otpauth-migration links to the totp algorithm registry
+var migrationAlgorithms = map[MigrationPayload_Algorithm]totp.Algo{
+	MigrationPayload_ALGORITHM_SHA1:   totp.SHA1,
+	MigrationPayload_ALGORITHM_SHA256: totp.SHA256,
+	MigrationPayload_ALGORITHM_SHA512: totp.SHA512,
+	MigrationPayload_ALGORITHM_MD5:    totp.MD5,
+}
+
 // AuthURIFromString parses an AuthURI from an otpauth:// string
 func AuthURIFromString(otpAuth string) (uri AuthURI, err error) {
 	var u *url.URL
@@ -69,7 +96,9 @@ func AuthURIFromString(otpAuth string) (uri AuthURI, err error) {
 	}
 	uri.Scheme = u.Scheme
 
-	if u.Host != "totp" {
+	switch u.Host {
+	case "totp", "hotp", "steam", "motp", "yaotp":
+	default:
 		return uri, fmt.Errorf("invalid host %s", u.Host)
 	}
 	uri.Type = u.Host
@@ -81,17 +110,24 @@ func AuthURIFromString(otpAuth string) (uri AuthURI, err error) {
 	c := u.Query()
 
 	d := c.Get("digits")
-	if d == "" {
-		uri.Digits = 6
-	} else {
+	switch {
+	case d != "":
 		uri.Digits, err = strconv.Atoi(d)
 		if err != nil {
 			return
 		}
+	case uri.Type == "steam":
+		uri.Digits = 5
+	case uri.Type == "yaotp":
+		uri.Digits = 8
+	default:
+		uri.Digits = 6
 	}
 
 	p := c.Get("period")
-	if p == "" {
+	if p == "" && uri.Type == "motp" {
+		uri.Period = 10
+	} else if p == "" {
 		uri.Period = 30
 	} else {
 		uri.Period, err = strconv.Atoi(p)
@@ -99,31 +135,37 @@ func AuthURIFromString(otpAuth string) (uri AuthURI, err error) {
 			return
 		}
 	}
+	if t0 := c.Get("t0"); t0 != "" {
+		uri.InitialCounterTime, err = strconv.ParseUint(t0, 10, 64)
+		if err != nil {
+			return
+		}
+	}
+	if uri.Type == "hotp" {
+		ctr := c.Get("counter")
+		if ctr == "" {
+			return uri, errors.New("counter required for hotp")
+		}
+		uri.Counter, err = strconv.Atoi(ctr)
+		if err != nil {
+			return
+		}
+	}
 	uri.Issuer = c.Get("issuer")
+	uri.PIN = c.Get("pin")
 
-	secStr := c.Get("secret")
-
-	if s, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secStr); err == nil {
-		uri.Secret = s
-	} else {
-		uri.Secret = []byte(secStr)
-	}
+	uri.Secret = decodeSecret(c.Get("secret"))
 
-	switch c.Get("algorithm") {
-	case "SHA1":
-		uri.Algorithm = totp.SHA1
-	case "SHA256":
-		uri.Algorithm = totp.SHA256
-	case "SHA512":
-		uri.Algorithm = totp.SHA512
-	default:
+	if a := c.Get("algorithm"); a == "" {
 		uri.Algorithm = totp.SHA1
+	} else if uri.Algorithm, err = totp.ParseAlgorithm(a); err != nil {
+		return
 	}
 
 	return
 }
 
-// NewAuthURI creates an AuthURI.
+// NewAuthURI creates an AuthURI. The secret is Base32 encoded, or used as the key as it is when it is not valid Base32.
 func NewAuthURI(label string, algo string, digits int, issuer string, secret string, period int) (AuthURI, error) {
 	a := AuthURI{
 		Scheme:  "otpauth",
@@ -131,20 +173,14 @@ func NewAuthURI(label string, algo string, digits int, issuer string, secret str
 		Counter: 0,
 	}
 
-	switch algo {
-	case "SHA1":
-		a.Algorithm = totp.SHA1
-	case "SHA256":
-		a.Algorithm = totp.SHA256
-	case "SHA512":
-		a.Algorithm = totp.SHA512
-	default:
-		return a, fmt.Errorf("invalid algorithm %s", algo)
+	var err error
+	if a.Algorithm, err = totp.ParseAlgorithm(algo); err != nil {
+		return a, err
 	}
 	if len(secret) == 0 {
 		return a, errors.New("secret required")
 	}
-	a.Secret = []byte(secret)
+	a.Secret = decodeSecret(secret)
 	if digits != 8 && digits != 6 {
 		return a, errors.New("digits must be 6 or 8")
 	}
@@ -156,6 +192,79 @@ func NewAuthURI(label string, algo string, digits int, issuer string, secret str
 	return a, nil
 }
 
+// NewRandomAuthURI creates a TOTP AuthURI with a new secret from GenerateSecret.
+func NewRandomAuthURI(label string, algo string, digits int, issuer string, period int) (AuthURI, error) {
+	a, err := totp.ParseAlgorithm(algo)
+	if err != nil {
+		return AuthURI{}, err
+	}
+	secret, err := GenerateSecret(a)
+	if err != nil {
+		return AuthURI{}, err
+	}
+	return NewAuthURI(label, algo, digits, issuer, secret, period)
+}
+
+// GenerateSecret returns a secret for algo from a cryptographically secure random source, Base32 encoded without
+// padding. The secret is as long as the output of the hash, which satisfies the minimum of 128 bits and the
+// recommendation of 160 bits in RFC 4226 section 4 for SHA1, and the key lengths used in RFC 6238 for SHA256 and SHA512.
+func GenerateSecret(algo totp.Algo) (string, error) {
+	h := algo.Hash()
+	if h == nil {
+		return "", totp.ErrInvalidAlgorithm
+	}
+	b := make([]byte, h().Size())
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// Rotate returns a copy of the AuthURI with a new secret from GenerateSecret, e.g. to replace a secret which may have
+// leaked. The counter of a hotp AuthURI is reset to 0.
+func (a AuthURI) Rotate() (AuthURI, error) {
+	secret, err := GenerateSecret(a.Algorithm)
+	if err != nil {
+		return a, err
+	}
+	a.Secret = decodeSecret(secret)
+	a.Counter = 0
+	return a, nil
+}
+
+// Rotation returns a pending totp.Rotation to the new secret of a, e.g. from Rotate, which accepts codes from the old
+// secret until graceUntil. The link of a is kept as the URI of the Rotation, from which AuthURIFromString recovers the
+// AuthURI to store once the Rotation is complete.
+func (a AuthURI) Rotation(graceUntil time.Time) (totp.Rotation, error) {
+	opts, err := a.TOTPOpts(nil)
+	if err != nil {
+		return totp.Rotation{}, err
+	}
+	if opts.Encoder != nil {
+		return totp.Rotation{}, fmt.Errorf("cannot rotate a %s AuthURI", a.Type)
+	}
+	return totp.Rotation{
+		Secret:             opts.Secret,
+		Algorithm:          opts.Algorithm.String(),
+		Digits:             opts.Digits,
+		Timestep:           opts.Timestep,
+		InitialCounterTime: opts.InitialCounterTime,
+		GraceUntil:         graceUntil,
+		URI:                a.URL().String(),
+	}, nil
+}
+
+// NewHOTPAuthURI creates an AuthURI for counter-based HOTP.
+func NewHOTPAuthURI(label string, algo string, digits int, issuer string, secret string, counter int) (AuthURI, error) {
+	a, err := NewAuthURI(label, algo, digits, issuer, secret, 0)
+	if err != nil {
+		return a, err
+	}
+	a.Type = "hotp"
+	a.Counter = counter
+	return a, nil
+}
+
 // URL returns a url.URL representation of an AuthURI
 func (a AuthURI) URL() *url.URL {
 	u := &url.URL{
@@ -166,17 +275,19 @@ func (a AuthURI) URL() *url.URL {
 	if len(a.Label) > 0 {
 		u.Path = fmt.Sprintf("/%s", a.Label)
 	}
-	switch a.Algorithm {
-	case totp.SHA1:
-		q.Add("algorithm", "SHA1")
-	case totp.SHA256:
-		q.Add("algorithm", "SHA256")
-	case totp.SHA512:
-		q.Add("algorithm", "SHA512")
+	if name := a.Algorithm.String(); name != "" {
+		q.Add("algorithm", name)
 	}
 	q.Add("digits", strconv.Itoa(a.Digits))
-	q.Add("period", strconv.Itoa(a.Period))
-	q.Add("secret", string(a.Secret))
+	if a.Type == "hotp" {
+		q.Add("counter", strconv.Itoa(a.Counter))
+	} else {
+		q.Add("period", strconv.Itoa(a.Period))
+		if a.InitialCounterTime != 0 {
+			q.Add("t0", strconv.FormatUint(a.InitialCounterTime, 10))
+		}
+	}
+	q.Add("secret", a.EncodedSecret())
 	if len(a.Issuer) > 0 {
 		q.Add("issuer", a.Issuer)
 	}
@@ -196,6 +307,15 @@ func (m MigrationURI) String() string {
 
 // MigrationURIDecode transforms a otpauth-migration type url.URL into a MigrationURL
 func MigrationURIDecode(u *url.URL) (m MigrationURI, err error) {
+	mp, err := migrationPayloadDecode(u)
+	if err != nil {
+		return nil, err
+	}
+	return migrationPayloadAuthURIs(mp)
+}
+
+// migrationPayloadDecode decodes the MigrationPayload of an otpauth-migration type url.URL
+func migrationPayloadDecode(u *url.URL) (*MigrationPayload, error) {
 	if u.Scheme != "otpauth-migration" {
 		return nil, fmt.Errorf("invalid scheme: Expected otpauth-migration got %s", u.Scheme)
 	}
@@ -213,19 +333,15 @@ func MigrationURIDecode(u *url.URL) (m MigrationURI, err error) {
 	if err := proto.Unmarshal(rs, &mp); err != nil {
 		return nil, err
 	}
+	return &mp, nil
+}
+
+// migrationPayloadAuthURIs transforms the accounts of a MigrationPayload into a MigrationURI
+func migrationPayloadAuthURIs(mp *MigrationPayload) (m MigrationURI, err error) {
 	for _, v := range mp.OtpParameters {
-		var a string
 		var d int
-		switch v.Algorithm {
-		case MigrationPayload_ALGORITHM_MD5:
-			return nil, errors.New("unsupported algorithm md5")
-		case MigrationPayload_ALGORITHM_SHA1:
-			a = "SHA1"
-		case MigrationPayload_ALGORITHM_SHA256:
-			a = "SHA256"
-		case MigrationPayload_ALGORITHM_SHA512:
-			a = "SHA512"
-		}
+		// the algorithm must be enabled in the registry, so MD5 is only accepted once enabled
+		a := migrationAlgorithms[v.Algorithm].String()
 		switch v.Digits {
 		case MigrationPayload_DIGIT_COUNT_UNSPECIFIED, MigrationPayload_DIGIT_COUNT_SIX:
 			d = 6
@@ -233,7 +349,12 @@ func MigrationURIDecode(u *url.URL) (m MigrationURI, err error) {
 			d = 8
 		}
 		secret := base32.StdEncoding.EncodeToString(v.Secret)
-		uri, err := NewAuthURI(v.Name, a, d, v.Issuer, secret, 30)
+		var uri AuthURI
+		if v.Type == MigrationPayload_OTP_TYPE_HOTP {
+			uri, err = NewHOTPAuthURI(v.Name, a, d, v.Issuer, secret, int(v.Counter))
+		} else {
+			uri, err = NewAuthURI(v.Name, a, d, v.Issuer, secret, 30)
+		}
 		if err != nil {
 			return m, err
 		}
@@ -243,30 +364,169 @@ func MigrationURIDecode(u *url.URL) (m MigrationURI, err error) {
 	return
 }
 
-// GenerateTOTPFromAuthURI generates a TOTP code from an AuthURI
-func GenerateTOTPFromAuthURI(otpAuth AuthURI, timestamp string) (code string, err error) {
-	var t int
-	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(string(otpAuth.Secret))
+// EncodedSecret returns the secret of an AuthURI Base32 encoded without padding, as in the secret parameter of links
+func (a AuthURI) EncodedSecret() string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(a.Secret)
+}
+
+// decodeSecret decodes a Base32 secret, with or without padding, returning it as it is when it is not valid Base32
+func decodeSecret(s string) []byte {
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(s, "="))
 	if err != nil || len(secret) == 0 {
-		secret = otpAuth.Secret
-	}
-	opts := totp.Opts{
-		Timestep:  uint(otpAuth.Period),
-		Secret:    secret,
-		Digits:    uint(otpAuth.Digits),
-		Algorithm: otpAuth.Algorithm,
+		return []byte(s)
 	}
+	return secret
+}
 
-	if timestamp != "" {
-		t, err = strconv.Atoi(timestamp)
+// MigrationURIEncode transforms a MigrationURI into one or more otpauth-migration type url.URL. As with Google
+// Authenticator exports, accounts are split into batches of at most MigrationBatchSize sharing a batch id.
+func MigrationURIEncode(m MigrationURI) (u []*url.URL, err error) {
+	if len(m) == 0 {
+		return nil, errors.New("no accounts to encode")
+	}
+	var params []*MigrationPayload_OtpParameters
+	for _, v := range m {
+		p, err := migrationOtpParameters(v)
 		if err != nil {
-			return
+			return nil, err
+		}
+		params = append(params, p)
+	}
+	var id [4]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	batchId := int32(binary.BigEndian.Uint32(id[:]) & 0x7fffffff)
+	batchSize := (len(params) + MigrationBatchSize - 1) / MigrationBatchSize
+	for i := 0; i < batchSize; i++ {
+		end := (i + 1) * MigrationBatchSize
+		if end > len(params) {
+			end = len(params)
+		}
+		mp := &MigrationPayload{
+			OtpParameters: params[i*MigrationBatchSize : end],
+			Version:       1,
+			BatchSize:     int32(batchSize),
+			BatchIndex:    int32(i),
+			BatchId:       batchId,
+		}
+		rs, err := proto.Marshal(mp)
+		if err != nil {
+			return nil, err
+		}
+		q := url.Values{}
+		q.Add("data", base64.StdEncoding.EncodeToString(rs))
+		u = append(u, &url.URL{Scheme: "otpauth-migration", Host: "offline", RawQuery: q.Encode()})
+	}
+	return u, nil
+}
+
+// migrationOtpParameters transforms an AuthURI into the otpauth-migration representation of an account
+func migrationOtpParameters(a AuthURI) (*MigrationPayload_OtpParameters, error) {
+	p := &MigrationPayload_OtpParameters{
+		Secret: a.Secret,
+		Name:   a.Label,
+		Issuer: a.Issuer,
+	}
+	for k, v := range migrationAlgorithms {
+		if v == a.Algorithm {
+			p.Algorithm = k
 		}
-		opts.CurrentUnixTime = uint64(t)
-	} else {
-		opts.CurrentUnixTime = uint64(time.Now().Unix())
 	}
+	if p.Algorithm == MigrationPayload_ALGORITHM_UNSPECIFIED || a.Algorithm.Hash() == nil {
+		return nil, fmt.Errorf("unsupported algorithm %s for %s", a.Algorithm, a.Label)
+	}
+	switch a.Digits {
+	case 6:
+		p.Digits = MigrationPayload_DIGIT_COUNT_SIX
+	case 8:
+		p.Digits = MigrationPayload_DIGIT_COUNT_EIGHT
+	default:
+		return nil, fmt.Errorf("unsupported digits %d for %s", a.Digits, a.Label)
+	}
+	switch a.Type {
+	case "hotp":
+		p.Type = MigrationPayload_OTP_TYPE_HOTP
+		p.Counter = int64(a.Counter)
+	case "totp":
+		// otpauth-migration has no period or t0, all TOTP accounts use 30 seconds from the Unix epoch
+		if a.Period != 30 {
+			return nil, fmt.Errorf("unsupported period %d for %s", a.Period, a.Label)
+		}
+		if a.InitialCounterTime != 0 {
+			return nil, fmt.Errorf("unsupported t0 %d for %s", a.InitialCounterTime, a.Label)
+		}
+		p.Type = MigrationPayload_OTP_TYPE_TOTP
+	default:
+		return nil, fmt.Errorf("unsupported type %s for %s", a.Type, a.Label)
+	}
+	return p, nil
+}
+
+// GenerateTOTPFromAuthURI generates a TOTP code from an AuthURI at the time of clock, or the system time when clock
+// is nil. Mobile-OTP and Yandex Key codes are generated for motp and yaotp AuthURIs.
+func GenerateTOTPFromAuthURI(otpAuth AuthURI, clock totp.Clock) (code string, err error) {
+	if otpAuth.Type == "hotp" {
+		return "", errors.New("cannot generate a TOTP code from a hotp AuthURI")
+	}
+	opts, err := otpAuth.opts(clock)
+	if err != nil {
+		return "", err
+	}
+	switch otpAuth.Type {
+	case "motp":
+		return totp.GenerateMOTP(opts, otpAuth.PIN)
+	case "yaotp":
+		return totp.GenerateYandex(opts, otpAuth.PIN)
+	default:
+		return totp.GenerateTOTP(opts)
+	}
+}
+
+// TOTPOpts returns the totp.Opts for generating or validating TOTP codes of an AuthURI at the time of clock, or the
+// system time when clock is nil
+func (a AuthURI) TOTPOpts(clock totp.Clock) (totp.Opts, error) {
+	switch a.Type {
+	case "hotp", "motp", "yaotp":
+		return totp.Opts{}, fmt.Errorf("cannot generate a TOTP code from a %s AuthURI", a.Type)
+	}
+	return a.opts(clock)
+}
+
+// opts returns the totp.Opts of an AuthURI at the time of clock
+func (a AuthURI) opts(clock totp.Clock) (totp.Opts, error) {
+	if a.Period <= 0 {
+		return totp.Opts{}, totp.ErrInvalidTimestep
+	}
+	opts := totp.Opts{
+		Timestep:           uint(a.Period),
+		InitialCounterTime: a.InitialCounterTime,
+		Secret:             a.Secret,
+		Digits:             uint(a.Digits),
+		Algorithm:          a.Algorithm,
+		Clock:              clock,
+	}
+	if a.Type == "steam" {
+		opts.Encoder = hotp.Steam
+	}
+	return opts, nil
+}
 
-	code = totp.GenerateTOTP(opts)
-	return code, nil
+// GenerateHOTPFromAuthURI generates a HOTP code from an AuthURI using its counter. The returned AuthURI has the counter
+// advanced to the next value and should be stored in place of the original.
+func GenerateHOTPFromAuthURI(otpAuth AuthURI) (code string, next AuthURI, err error) {
+	if otpAuth.Type != "hotp" {
+		return "", otpAuth, fmt.Errorf("cannot generate a HOTP code from a %s AuthURI", otpAuth.Type)
+	}
+	if otpAuth.Counter < 0 {
+		return "", otpAuth, errors.New("counter must not be negative")
+	}
+	opts := totp.Opts{Algorithm: otpAuth.Algorithm}
+	code, err = hotp.GenerateHOTP(opts.Algo(), otpAuth.Secret, uint64(otpAuth.Counter), uint(otpAuth.Digits))
+	if err != nil {
+		return "", otpAuth, err
+	}
+	next = otpAuth
+	next.Counter++
+	return code, next, nil
 }
@@ -0,0 +1,329 @@
+package otpauth
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/hotp"
+	"github.com/richardjennings/totp/pkg/totp"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHOTPAuthURI(t *testing.T) {
+	link := "otpauth://hotp/me?algorithm=SHA1&counter=1&digits=6&issuer=myorg&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	uri, err := AuthURIFromString(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Type != "hotp" || uri.Counter != 1 {
+		t.Errorf("expected hotp with counter 1 got %s with counter %d", uri.Type, uri.Counter)
+	}
+	if uri.URL().String() != link {
+		t.Errorf("expected %s got %s", link, uri.URL().String())
+	}
+	for _, expected := range []string{"287082", "359152", "969429"} {
+		var code string
+		code, uri, err = GenerateHOTPFromAuthURI(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Errorf("expected %s got %s", expected, code)
+		}
+	}
+	if uri.Counter != 4 {
+		t.Errorf("expected counter 4 got %d", uri.Counter)
+	}
+	if _, err := AuthURIFromString("otpauth://hotp/me?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"); err == nil {
+		t.Error("expected error for hotp without counter")
+	}
+}
+
+func TestSteamAuthURI(t *testing.T) {
+	link := "otpauth://steam/Steam:alice?issuer=Steam&secret=ON2XAZLSMR2XAZLSONSWG4TFOQ"
+	uri, err := AuthURIFromString(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Type != "steam" || uri.Digits != 5 {
+		t.Errorf("expected steam with 5 digits got %s with %d", uri.Type, uri.Digits)
+	}
+	code, err := GenerateTOTPFromAuthURI(uri, totp.FixedClock{Time: time.Unix(3000030, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "YRGQJ" {
+		t.Errorf("expected YRGQJ got %s", code)
+	}
+	expected := "otpauth://steam/Steam:alice?algorithm=SHA1&digits=5&issuer=Steam&period=30&secret=ON2XAZLSMR2XAZLSONSWG4TFOQ"
+	if uri.URL().String() != expected {
+		t.Errorf("expected %s got %s", expected, uri.URL().String())
+	}
+	if _, err := MigrationURIEncode(MigrationURI{uri}); err == nil {
+		t.Error("expected error encoding a steam AuthURI as a migration link")
+	}
+}
+
+func TestPINAuthURI(t *testing.T) {
+	for _, tcase := range []struct {
+		link     string
+		time     int64
+		code     string
+		expected string
+	}{
+		{
+			"otpauth://motp/alice?secret=4MKSV7XGEWM4Q&pin=1234",
+			165892298,
+			"e7d8b6",
+			"otpauth://motp/alice?algorithm=SHA1&digits=6&period=10&secret=4MKSV7XGEWM4Q",
+		},
+		{
+			"otpauth://yaotp/alice?secret=6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY&pin=5239",
+			1641559648,
+			"umozdicq",
+			"otpauth://yaotp/alice?algorithm=SHA1&digits=8&period=30&secret=6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY",
+		},
+	} {
+		uri, err := AuthURIFromString(tcase.link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := GenerateTOTPFromAuthURI(uri, totp.FixedClock{Time: time.Unix(tcase.time, 0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tcase.code {
+			t.Errorf("expected %s got %s", tcase.code, code)
+		}
+		// the PIN is not included in links
+		if uri.URL().String() != tcase.expected {
+			t.Errorf("expected %s got %s", tcase.expected, uri.URL().String())
+		}
+		if _, err := uri.TOTPOpts(nil); err == nil {
+			t.Errorf("expected error for TOTP options of a %s AuthURI", uri.Type)
+		}
+	}
+}
+
+func TestAlgorithmRegistry(t *testing.T) {
+	// the registry is shared by the process, so disable the algorithms enabled here for other tests
+	t.Cleanup(func() {
+		for _, name := range []string{"MD5", "SHA3-256"} {
+			if err := totp.DisableAlgorithm(name); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	mp := &MigrationPayload{OtpParameters: []*MigrationPayload_OtpParameters{{
+		Secret:    []byte("12345678901234567890"),
+		Name:      "md5@myorg",
+		Algorithm: MigrationPayload_ALGORITHM_MD5,
+		Digits:    MigrationPayload_DIGIT_COUNT_SIX,
+		Type:      MigrationPayload_OTP_TYPE_TOTP,
+	}}}
+	link := "otpauth://totp/md5@myorg?algorithm=MD5&digits=6&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if _, err := migrationPayloadAuthURIs(mp); !errors.Is(err, totp.ErrInvalidAlgorithm) {
+		t.Errorf("expected %v before enabling MD5 got %v", totp.ErrInvalidAlgorithm, err)
+	}
+	if _, err := AuthURIFromString(link); !errors.Is(err, totp.ErrInvalidAlgorithm) {
+		t.Errorf("expected %v before enabling MD5 got %v", totp.ErrInvalidAlgorithm, err)
+	}
+	if err := totp.EnableAlgorithm("MD5"); err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrationPayloadAuthURIs(mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m[0].Algorithm != totp.MD5 || m[0].URL().String() != link {
+		t.Errorf("expected %s got %s", link, m[0].URL().String())
+	}
+	if _, err := MigrationURIEncode(m); err != nil {
+		t.Error(err)
+	}
+	// MD5 links can be imported and exported but not used to generate codes
+	if _, err := GenerateTOTPFromAuthURI(m[0], nil); err != hotp.ErrInvalidHash {
+		t.Errorf("expected %v got %v", hotp.ErrInvalidHash, err)
+	}
+
+	// algorithms without an otpauth-migration representation cannot be exported
+	if err := totp.EnableAlgorithm("SHA3-256"); err != nil {
+		t.Fatal(err)
+	}
+	uri, err := AuthURIFromString("otpauth://totp/sha3@myorg?algorithm=SHA3-256&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Algorithm != totp.SHA3_256 {
+		t.Errorf("expected %s got %s", totp.SHA3_256, uri.Algorithm)
+	}
+	if _, err := MigrationURIEncode(MigrationURI{uri}); err == nil {
+		t.Error("expected error encoding SHA3-256 as a migration link")
+	}
+	if _, err := AuthURIFromString("otpauth://totp/x?algorithm=SHA0&secret=GEZDGNBVGY3TQOJQ"); !errors.Is(err, totp.ErrInvalidAlgorithm) {
+		t.Errorf("expected %v got %v", totp.ErrInvalidAlgorithm, err)
+	}
+}
+
+func TestMigrationURIEncode(t *testing.T) {
+	var m MigrationURI
+	for i := 0; i < 25; i++ {
+		a, err := NewAuthURI(fmt.Sprintf("totp%d@myorg", i), "SHA1", 6, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m = append(m, a)
+	}
+	h, err := NewHOTPAuthURI("hotp@myorg", "SHA256", 8, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = append(m, h)
+
+	links, err := MigrationURIEncode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links got %d", len(links))
+	}
+	var decoded MigrationURI
+	for _, l := range links {
+		d, err := MigrationURIDecode(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, d...)
+	}
+	if len(decoded) != len(m) {
+		t.Fatalf("expected %d accounts got %d", len(m), len(decoded))
+	}
+	for i := range m {
+		if decoded[i].URL().String() != m[i].URL().String() {
+			t.Errorf("expected %s got %s", m[i].URL(), decoded[i].URL())
+		}
+	}
+
+	if _, err := MigrationURIEncode(MigrationURI{{Type: "totp", Algorithm: totp.SHA1, Digits: 6, Period: 60}}); err == nil {
+		t.Error("expected error for unsupported period")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	for _, tcase := range []struct {
+		algo   totp.Algo
+		length int
+	}{
+		{totp.SHA1, 20},
+		{totp.SHA256, 32},
+		{totp.SHA512, 64},
+	} {
+		s, err := GenerateSecret(tcase.algo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b) != tcase.length {
+			t.Errorf("expected %d bytes got %d", tcase.length, len(b))
+		}
+		if s2, _ := GenerateSecret(tcase.algo); s2 == s {
+			t.Error("expected different secrets")
+		}
+	}
+	if _, err := GenerateSecret(totp.Invalid); err != totp.ErrInvalidAlgorithm {
+		t.Errorf("expected %v got %v", totp.ErrInvalidAlgorithm, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	a, err := NewHOTPAuthURI("hotp@myorg", "SHA256", 8, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := a.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(r.Secret, a.Secret) || len(r.Secret) != 32 {
+		t.Errorf("expected a new 32 byte secret got %s", r.Secret)
+	}
+	if r.Counter != 0 {
+		t.Errorf("expected counter 0 got %d", r.Counter)
+	}
+	r.Secret, r.Counter = a.Secret, a.Counter
+	if r.URL().String() != a.URL().String() {
+		t.Errorf("expected %s got %s", a.URL(), r.URL())
+	}
+}
+
+func TestRotation(t *testing.T) {
+	a, err := AuthURIFromString("otpauth://totp/totp@myorg?algorithm=SHA1&digits=6&issuer=myorg&period=30&secret=ONXW2ZLTMVRXEZLU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := a.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	grace := time.Unix(1111111109, 0)
+	r, err := next.Rotation(grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.GraceUntil.Equal(grace) || r.URI != next.URL().String() || !bytes.Equal(r.Secret, next.Secret) {
+		t.Errorf("expected a rotation to %s got %v", r.URI, r)
+	}
+	opts, err := r.Opts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := next.TOTPOpts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Errorf("expected %+v got %+v", expected, opts)
+	}
+	steam, err := AuthURIFromString("otpauth://steam/Steam:alice?secret=ON2XAZLSMR2XAZLSONSWG4TFOQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := steam.Rotation(grace); err == nil {
+		t.Error("expected error rotating a steam AuthURI")
+	}
+}
+
+func TestAuthURISecret(t *testing.T) {
+	for _, tcase := range []struct {
+		secret  string
+		key     string
+		encoded string
+	}{
+		{"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "12345678901234567890", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"},
+		{"ONXW2ZLTMVRXEZLU", "somesecret", "ONXW2ZLTMVRXEZLU"},
+		{"ONXW2ZLTMVRXEZLU%3D%3D%3D%3D", "somesecret", "ONXW2ZLTMVRXEZLU"},
+		// secrets which are not valid Base32 are used as they are
+		{"thesharedsecret", "thesharedsecret", "ORUGK43IMFZGKZDTMVRXEZLU"},
+	} {
+		uri, err := AuthURIFromString("otpauth://totp/me?secret=" + tcase.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(uri.Secret) != tcase.key || uri.EncodedSecret() != tcase.encoded {
+			t.Errorf("expected %s %s got %s %s", tcase.key, tcase.encoded, uri.Secret, uri.EncodedSecret())
+		}
+		a, err := NewAuthURI("me", "SHA1", 6, "", tcase.secret, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tcase.secret == tcase.encoded && string(a.Secret) != tcase.key {
+			t.Errorf("expected %s got %s", tcase.key, a.Secret)
+		}
+	}
+}
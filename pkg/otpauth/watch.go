@@ -0,0 +1,57 @@
+package otpauth
+
+import (
+	"context"
+	"github.com/richardjennings/totp/pkg/totp"
+	"sync"
+)
+
+// Event is a TOTP for an account of a MigrationURI and the period during which it is valid
+type Event struct {
+	// the position of the account in the MigrationURI
+	Index   int
+	Account AuthURI
+	totp.Tick
+}
+
+// Watch returns a channel on which an Event is sent for the current code of each account and then for each new code
+// as its period begins, using totp.Ticker. Accounts may have different periods. The channel is closed when ctx is
+// cancelled.
+func Watch(ctx context.Context, m MigrationURI, clock totp.Clock) (<-chan Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var tickers []<-chan totp.Tick
+	for _, v := range m {
+		opts, err := v.TOTPOpts(clock)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		t, err := totp.Ticker(ctx, opts)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		tickers = append(tickers, t)
+	}
+	ch := make(chan Event)
+	var wg sync.WaitGroup
+	for i, t := range tickers {
+		wg.Add(1)
+		go func(i int, t <-chan totp.Tick) {
+			defer wg.Done()
+			for tick := range t {
+				select {
+				case ch <- Event{Index: i, Account: m[i], Tick: tick}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, t)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,41 @@
+package otpauth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	var m MigrationURI
+	for _, period := range []int{1, 2} {
+		a, err := NewAuthURI("totp@myorg", "SHA1", 6, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", period)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m = append(m, a)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ch, err := Watch(ctx, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[int]int{}
+	for e := range ch {
+		if d := e.ValidUntil.Sub(e.ValidFrom); d != time.Duration(m[e.Index].Period)*time.Second {
+			t.Errorf("expected a period of %ds got %s", m[e.Index].Period, d)
+		}
+		counts[e.Index]++
+		if counts[0] >= 3 && counts[1] >= 2 {
+			cancel()
+		}
+	}
+	if counts[0] < 3 || counts[1] < 2 {
+		t.Errorf("expected events for both accounts got %v", counts)
+	}
+
+	if _, err := Watch(context.Background(), MigrationURI{{Type: "hotp", Period: 30}}, nil); err == nil {
+		t.Error("expected error watching a hotp account")
+	}
+}
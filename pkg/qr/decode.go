@@ -0,0 +1,48 @@
+package qr
+
+import (
+	"errors"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"image"
+	// register the image formats QR codes are decoded from
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+)
+
+// ErrNotFound is returned when no QR code can be found in an image
+var ErrNotFound = errors.New("no QR code found in image")
+
+// Decode returns the text of the QR code in a PNG or JPEG image read from r. Decoding is done locally so that secrets
+// contained in the QR code are never sent elsewhere.
+func Decode(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", err
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	res, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		if _, ok := err.(gozxing.NotFoundException); ok {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return res.GetText(), nil
+}
+
+// DecodeFile returns the text of the QR code in the PNG or JPEG image file at path
+func DecodeFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	return Decode(f)
+}
@@ -0,0 +1,87 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+func TestDecode(t *testing.T) {
+	link := "otpauth-migration://offline?data=CiUKCnNvbWVzZWNyZXQSCnRvdHBAbXlvcmcaBW15b3JnIAEoATACEAEYASAA"
+	b, err := qrcode.Encode(link, qrcode.Medium, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != link {
+		t.Errorf("expected %s got %s", link, text)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j bytes.Buffer
+	if err := jpeg.Encode(&j, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	text, err = Decode(&j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != link {
+		t.Errorf("expected %s got %s", link, text)
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(&b); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	s, err := SVG("otpauth://totp/totp@myorg?secret=ONXW2ZLTMVRXEZLU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "<svg") || !strings.HasSuffix(s, "</svg>") || !strings.Contains(s, "M") {
+		t.Errorf("unexpected svg %s", s)
+	}
+}
+
+func TestDataURI(t *testing.T) {
+	link := "otpauth://totp/totp@myorg?secret=ONXW2ZLTMVRXEZLU"
+	s, err := DataURI(link, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const prefix = "data:image/png;base64,"
+	if !strings.HasPrefix(s, prefix) {
+		t.Fatalf("unexpected data uri %s", s)
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, err := Decode(bytes.NewReader(b)); err != nil || text != link {
+		t.Errorf("expected %s got %s %v", link, text, err)
+	}
+}
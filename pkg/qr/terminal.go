@@ -0,0 +1,94 @@
+package qr
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// ErrInvalidQuietZone is returned when TerminalOpts.QuietZone is negative
+var ErrInvalidQuietZone = errors.New("quiet zone must not be negative")
+
+const (
+	ansiLight = "\x1b[47m  "
+	ansiDark  = "\x1b[40m  "
+	ansiReset = "\x1b[0m"
+)
+
+// TerminalOpts configures how a QR code is rendered as text by WriteTerminal
+type TerminalOpts struct {
+	// render each module as two spaces with ANSI background colours instead of Unicode half blocks, which some
+	// terminal fonts draw with gaps between lines
+	ANSI bool
+	// swap light and dark. Half blocks are drawn in the terminal's text colour, which by default is assumed to be light
+	// on a dark background; set Invert for terminals with dark text on a light background
+	Invert bool
+	// the width in modules of the light border around the QR code. Scanners expect 4 but often cope with less
+	QuietZone int
+}
+
+// WriteTerminal renders content as a QR code to w using text, so that it can be scanned from a terminal without an
+// image viewer, e.g. over SSH.
+func WriteTerminal(w io.Writer, content string, opts TerminalOpts) error {
+	if opts.QuietZone < 0 {
+		return ErrInvalidQuietZone
+	}
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	q.DisableBorder = true
+	bitmap := q.Bitmap()
+
+	// dark reports whether the module at x, y is dark, including the quiet zone. Modules outside of the QR code and
+	// quiet zone are light
+	size := len(bitmap) + 2*opts.QuietZone
+	dark := func(x int, y int) bool {
+		x -= opts.QuietZone
+		y -= opts.QuietZone
+		if y < 0 || y >= len(bitmap) || x < 0 || x >= len(bitmap[y]) {
+			return false
+		}
+		return bitmap[y][x]
+	}
+
+	b := bufio.NewWriter(w)
+	if opts.ANSI {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if dark(x, y) != opts.Invert {
+					_, _ = b.WriteString(ansiDark)
+				} else {
+					_, _ = b.WriteString(ansiLight)
+				}
+			}
+			_, _ = b.WriteString(ansiReset + "\n")
+		}
+		return b.Flush()
+	}
+
+	// each line of half blocks draws two rows of modules, the glyph is drawn where the module is light. The size is
+	// odd, so the bottom of the last line is beyond the QR code and drawn light
+	for y := 0; y < size; y += 2 {
+		var line strings.Builder
+		for x := 0; x < size; x++ {
+			top := dark(x, y) == opts.Invert
+			bottom := dark(x, y+1) == opts.Invert
+			switch {
+			case top && bottom:
+				line.WriteString("█")
+			case top:
+				line.WriteString("▀")
+			case bottom:
+				line.WriteString("▄")
+			default:
+				line.WriteString(" ")
+			}
+		}
+		_, _ = b.WriteString(line.String() + "\n")
+	}
+	return b.Flush()
+}
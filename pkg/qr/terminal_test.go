@@ -0,0 +1,102 @@
+package qr
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestWriteTerminal(t *testing.T) {
+	link := "otpauth://totp/totp@myorg?algorithm=SHA1&digits=6&issuer=myorg&period=30&secret=ONXW2ZLTMVRXEZLU"
+	for _, opts := range []TerminalOpts{
+		{QuietZone: 4},
+		{QuietZone: 1, Invert: true},
+		{QuietZone: 2, ANSI: true},
+		{QuietZone: 2, ANSI: true, Invert: true},
+	} {
+		var b bytes.Buffer
+		if err := WriteTerminal(&b, link, opts); err != nil {
+			t.Fatal(err)
+		}
+		text, err := Decode(terminalImage(t, b.String(), opts))
+		if err != nil {
+			t.Fatalf("%+v: %s", opts, err)
+		}
+		if text != link {
+			t.Errorf("%+v: expected %s got %s", opts, link, text)
+		}
+	}
+}
+
+// terminalImage converts the output of WriteTerminal back into a PNG image with a light border
+func terminalImage(t *testing.T, s string, opts TerminalOpts) *bytes.Buffer {
+	var rows [][]bool
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		var top, bottom []bool
+		if opts.ANSI {
+			line = strings.TrimSuffix(line, ansiReset)
+			for i := 0; i < len(line); i += len(ansiDark) {
+				top = append(top, (line[i:i+len(ansiDark)] == ansiDark) != opts.Invert)
+			}
+			rows = append(rows, top)
+			continue
+		}
+		for _, c := range line {
+			// a glyph is drawn where the module is light unless inverted
+			t, b := c == '█' || c == '▀', c == '█' || c == '▄'
+			top = append(top, t == opts.Invert)
+			bottom = append(bottom, b == opts.Invert)
+		}
+		rows = append(rows, top, bottom)
+	}
+	const scale, border = 4, 8
+	img := image.NewGray(image.Rect(0, 0, len(rows[0])*scale+2*border, len(rows)*scale+2*border))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range rows {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[img.PixOffset(border+x*scale+dx, border+y*scale+dy)] = 0
+				}
+			}
+		}
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	return &b
+}
+
+func TestWriteTerminalOddSize(t *testing.T) {
+	link := "otpauth://totp/totp@myorg?secret=ONXW2ZLTMVRXEZLU"
+	// QR codes have an odd number of modules, so the bottom of the last line of half blocks is beyond the QR code and
+	// must be drawn light: with a glyph unless inverted
+	for _, opts := range []TerminalOpts{{QuietZone: 0}, {QuietZone: 0, Invert: true}, {QuietZone: 1}} {
+		var b bytes.Buffer
+		if err := WriteTerminal(&b, link, opts); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		for _, c := range lines[len(lines)-1] {
+			if bottom := c == '█' || c == '▄'; bottom == opts.Invert {
+				t.Errorf("%+v: expected a light bottom row got %q", opts, c)
+				break
+			}
+		}
+	}
+}
+
+func TestWriteTerminalInvalidQuietZone(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteTerminal(&b, "otpauth://totp/x?secret=ONXW2ZLTMVRXEZLU", TerminalOpts{QuietZone: -1}); err != ErrInvalidQuietZone {
+		t.Errorf("expected %v got %v", ErrInvalidQuietZone, err)
+	}
+}
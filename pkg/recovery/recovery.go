@@ -0,0 +1,108 @@
+package recovery
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+const (
+	// DefaultCount is the number of recovery codes in a set when none is given
+	DefaultCount = 10
+	// codeLength is the number of symbols in a recovery code, 80 bits, excluding separators
+	codeLength = 16
+	// groupLength is the number of symbols between separators
+	groupLength = 4
+	// saltLength is the number of random bytes of salt per recovery code
+	saltLength = 16
+	// alphabet is the lower case Crockford Base32 alphabet, which omits i, l, o and u to avoid misreading
+	alphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+)
+
+// ErrInvalidCode is returned when a recovery code does not match any unused recovery code
+var ErrInvalidCode = errors.New("invalid recovery code")
+
+// Hash is the salted SHA-256 hash of a recovery code, safe to store in place of the code
+type Hash struct {
+	Salt []byte `json:"salt"`
+	Sum  []byte `json:"sum"`
+}
+
+// Generate returns n random recovery codes of the form xxxx-xxxx-xxxx-xxxx to show to the user once, and the hashes of
+// the codes for storage. DefaultCount codes are generated when n is 0.
+func Generate(n int) (codes []string, hashes []Hash, err error) {
+	if n < 0 {
+		return nil, nil, errors.New("count must not be negative")
+	}
+	if n == 0 {
+		n = DefaultCount
+	}
+	for i := 0; i < n; i++ {
+		b := make([]byte, codeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		var code strings.Builder
+		for j, v := range b {
+			if j > 0 && j%groupLength == 0 {
+				code.WriteByte('-')
+			}
+			// len(alphabet) divides 256 so every symbol is equally likely
+			code.WriteByte(alphabet[int(v)%len(alphabet)])
+		}
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code.String())
+		hashes = append(hashes, Hash{Salt: salt, Sum: sum(salt, normalize(code.String()))})
+	}
+	return codes, hashes, nil
+}
+
+// Use checks code against hashes and, when it matches, returns the hashes with the matching hash removed, which should
+// be stored in place of hashes so that the code cannot be used again. The number of recovery codes which remain is
+// len(remaining). Every hash is compared in constant time so that the time taken does not reveal which, if any, hash
+// matched. Case, separators and whitespace in code are ignored, and i, l and o are read as 1, 1 and 0.
+func Use(hashes []Hash, code string) (remaining []Hash, err error) {
+	c := normalize(code)
+	match := -1
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(sum(h.Salt, c), h.Sum) == 1 && match == -1 {
+			match = i
+		}
+	}
+	if match == -1 {
+		return hashes, ErrInvalidCode
+	}
+	remaining = make([]Hash, 0, len(hashes)-1)
+	remaining = append(remaining, hashes[:match]...)
+	return append(remaining, hashes[match+1:]...), nil
+}
+
+// normalize returns code in the form it is hashed, lower case without separators
+func normalize(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(code) {
+		switch r {
+		case '-', ' ', '\t':
+		case 'i', 'l':
+			b.WriteByte('1')
+		case 'o':
+			b.WriteByte('0')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sum returns the SHA-256 hash of salt followed by code
+func sum(salt []byte, code string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(code))
+	return h.Sum(nil)
+}
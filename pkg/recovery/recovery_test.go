@@ -0,0 +1,73 @@
+package recovery
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	codes, hashes, err := Generate(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != DefaultCount || len(hashes) != DefaultCount {
+		t.Fatalf("expected %d codes got %d codes and %d hashes", DefaultCount, len(codes), len(hashes))
+	}
+	format := regexp.MustCompile(`^[0-9a-hjkmnp-tv-z]{4}(-[0-9a-hjkmnp-tv-z]{4}){3}$`)
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("unexpected code format %s", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %s", code)
+		}
+		seen[code] = true
+		if string(hashes[i].Sum) == code || len(hashes[i].Salt) != saltLength {
+			t.Errorf("unexpected hash %v", hashes[i])
+		}
+	}
+	if _, _, err := Generate(-1); err == nil {
+		t.Error("expected error for negative count")
+	}
+}
+
+func TestUse(t *testing.T) {
+	codes, hashes, err := Generate(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := Use(hashes, "0000-0000-0000-0000")
+	if err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+	if len(remaining) != 3 {
+		t.Errorf("expected 3 remaining got %d", len(remaining))
+	}
+	// codes are accepted regardless of case and separators
+	remaining, err = Use(hashes, strings.ToUpper(strings.Replace(codes[1], "-", " ", -1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("expected 2 remaining got %d", len(remaining))
+	}
+	if _, err := Use(remaining, codes[1]); err != ErrInvalidCode {
+		t.Errorf("expected used code to be rejected, got %v", err)
+	}
+	for _, code := range []string{codes[0], codes[2]} {
+		if remaining, err = Use(remaining, code); err != nil {
+			t.Error(err)
+		}
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected 0 remaining got %d", len(remaining))
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if v := normalize(" AbIl-O9 "); v != "ab1109" {
+		t.Errorf("unexpected normalized code %s", v)
+	}
+}
@@ -0,0 +1,115 @@
+package totp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type algorithm struct {
+	name    string
+	hash    func() hash.Hash
+	enabled bool
+}
+
+// algorithms is the registry of algorithms by Algo. SHA1, SHA256 and SHA512 are enabled by default, MD5, SHA3_256 and
+// SHA3_512 must be enabled with EnableAlgorithm.
+var algorithms = struct {
+	sync.RWMutex
+	byAlgo map[Algo]algorithm
+	next   Algo
+}{
+	byAlgo: map[Algo]algorithm{
+		SHA1:     {"SHA1", sha1.New, true},
+		SHA256:   {"SHA256", sha256.New, true},
+		SHA512:   {"SHA512", sha512.New, true},
+		MD5:      {"MD5", md5.New, false},
+		SHA3_256: {"SHA3-256", sha3.New256, false},
+		SHA3_512: {"SHA3-512", sha3.New512, false},
+	},
+	next: SHA3_512 + 1,
+}
+
+// RegisterAlgorithm registers and enables a hash function under name, e.g. as used in the algorithm parameter of
+// otpauth links, returning the Algo which identifies it. As with EnableAlgorithm the registration is process-wide.
+func RegisterAlgorithm(name string, h func() hash.Hash) (Algo, error) {
+	if name == "" || h == nil {
+		return Invalid, ErrInvalidAlgorithm
+	}
+	algorithms.Lock()
+	defer algorithms.Unlock()
+	for _, v := range algorithms.byAlgo {
+		if v.name == name {
+			return Invalid, fmt.Errorf("algorithm %s already registered", name)
+		}
+	}
+	a := algorithms.next
+	algorithms.next++
+	algorithms.byAlgo[a] = algorithm{name: name, hash: h, enabled: true}
+	return a, nil
+}
+
+// EnableAlgorithm enables the algorithm registered under name which is disabled by default, e.g. MD5. The registry is
+// shared by the whole process, so the algorithm is enabled for every caller.
+func EnableAlgorithm(name string) error {
+	return setEnabled(name, true)
+}
+
+// DisableAlgorithm disables the algorithm registered under name for the whole process, so that it is no longer
+// accepted by ParseAlgorithm or used to generate codes
+func DisableAlgorithm(name string) error {
+	return setEnabled(name, false)
+}
+
+// setEnabled enables or disables the algorithm registered under name
+func setEnabled(name string, enabled bool) error {
+	algorithms.Lock()
+	defer algorithms.Unlock()
+	for a, v := range algorithms.byAlgo {
+		if v.name == name {
+			v.enabled = enabled
+			algorithms.byAlgo[a] = v
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %s", ErrInvalidAlgorithm, name)
+}
+
+// ParseAlgorithm returns the enabled Algo registered under name
+func ParseAlgorithm(name string) (Algo, error) {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+	for a, v := range algorithms.byAlgo {
+		if v.name == name {
+			if !v.enabled {
+				return Invalid, fmt.Errorf("%w %s is not enabled", ErrInvalidAlgorithm, name)
+			}
+			return a, nil
+		}
+	}
+	return Invalid, fmt.Errorf("%w %s", ErrInvalidAlgorithm, name)
+}
+
+// String returns the name the Algo is registered under, or an empty string when it is not registered
+func (a Algo) String() string {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+	return algorithms.byAlgo[a].name
+}
+
+// Hash returns the hash function of the Algo, or nil when it is not registered or not enabled
+func (a Algo) Hash() func() hash.Hash {
+	algorithms.RLock()
+	defer algorithms.RUnlock()
+	v := algorithms.byAlgo[a]
+	if !v.enabled {
+		return nil
+	}
+	return v.hash
+}
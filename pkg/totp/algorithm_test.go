@@ -0,0 +1,114 @@
+package totp
+
+import (
+	"crypto/sha256"
+	"errors"
+	"github.com/richardjennings/totp/pkg/hotp"
+	"testing"
+	"time"
+)
+
+// restoreAlgorithms restores the registry of algorithms to its current state when the test completes
+func restoreAlgorithms(t *testing.T) {
+	algorithms.RLock()
+	byAlgo := make(map[Algo]algorithm, len(algorithms.byAlgo))
+	for a, v := range algorithms.byAlgo {
+		byAlgo[a] = v
+	}
+	next := algorithms.next
+	algorithms.RUnlock()
+	t.Cleanup(func() {
+		algorithms.Lock()
+		defer algorithms.Unlock()
+		algorithms.byAlgo, algorithms.next = byAlgo, next
+	})
+}
+
+func TestEnableAlgorithm(t *testing.T) {
+	restoreAlgorithms(t)
+	opts := Opts{
+		Timestep: 30,
+		Digits:   8,
+		Clock:    FixedClock{Time: time.Unix(59, 0)},
+	}
+	// expected codes computed independently with Python's hmac and hashlib. The 16 byte MD5 HMAC is too short for
+	// dynamic truncation, so MD5 can be enabled but not used to generate codes
+	for _, tcase := range []struct {
+		name   string
+		algo   Algo
+		secret string
+		digits uint
+		code   string
+		err    error
+	}{
+		{"MD5", MD5, "12345678901234567890", 6, "", hotp.ErrInvalidHash},
+		{"SHA3-256", SHA3_256, "12345678901234567890123456789012", 8, "03503818", nil},
+		{"SHA3-512", SHA3_512, "1234567890123456789012345678901234567890123456789012345678901234", 8, "01892432", nil},
+	} {
+		opts.Algorithm, opts.Secret, opts.Digits = tcase.algo, []byte(tcase.secret), tcase.digits
+		if _, err := GenerateTOTP(opts); err != ErrInvalidAlgorithm {
+			t.Errorf("%s: expected %v before enabling got %v", tcase.name, ErrInvalidAlgorithm, err)
+		}
+		if _, err := ParseAlgorithm(tcase.name); !errors.Is(err, ErrInvalidAlgorithm) {
+			t.Errorf("%s: expected %v before enabling got %v", tcase.name, ErrInvalidAlgorithm, err)
+		}
+		if err := EnableAlgorithm(tcase.name); err != nil {
+			t.Fatal(err)
+		}
+		if a, err := ParseAlgorithm(tcase.name); err != nil || a != tcase.algo || a.String() != tcase.name {
+			t.Errorf("%s: expected %d got %d %v", tcase.name, tcase.algo, a, err)
+		}
+		code, err := GenerateTOTP(opts)
+		if err != tcase.err {
+			t.Fatalf("%s: expected %v got %v", tcase.name, tcase.err, err)
+		}
+		if code != tcase.code {
+			t.Errorf("%s: expected %s got %s", tcase.name, tcase.code, code)
+		}
+	}
+	if err := EnableAlgorithm("SHA0"); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+}
+
+func TestDisableAlgorithm(t *testing.T) {
+	restoreAlgorithms(t)
+	if err := DisableAlgorithm("SHA256"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAlgorithm("SHA256"); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+	opts := Opts{Timestep: 30, Digits: 8, Secret: []byte("12345678901234567890123456789012"), Algorithm: SHA256, Clock: FixedClock{Time: time.Unix(59, 0)}}
+	if _, err := GenerateTOTP(opts); err != ErrInvalidAlgorithm {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+	if err := DisableAlgorithm("SHA0"); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	restoreAlgorithms(t)
+	a, err := RegisterAlgorithm("TEST-SHA256", sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != "TEST-SHA256" {
+		t.Errorf("expected TEST-SHA256 got %s", a)
+	}
+	if p, err := ParseAlgorithm("TEST-SHA256"); err != nil || p != a {
+		t.Errorf("expected %d got %d %v", a, p, err)
+	}
+	// a registered algorithm generates the same codes as the built in algorithm with the same hash
+	opts := Opts{Timestep: 30, Digits: 8, Secret: []byte("12345678901234567890123456789012"), Algorithm: a, Clock: FixedClock{Time: time.Unix(59, 0)}}
+	if code, err := GenerateTOTP(opts); err != nil || code != "46119246" {
+		t.Errorf("expected 46119246 got %s %v", code, err)
+	}
+	if _, err := RegisterAlgorithm("SHA1", sha256.New); err == nil {
+		t.Error("expected error registering an existing name")
+	}
+	if _, err := RegisterAlgorithm("NIL", nil); err != ErrInvalidAlgorithm {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+}
@@ -0,0 +1,47 @@
+package totp
+
+import "time"
+
+type (
+	// Clock provides the current time to TOTP generation and validation
+	Clock interface {
+		Now() time.Time
+	}
+
+	// RealClock is a Clock returning the system time
+	RealClock struct{}
+
+	// FixedClock is a Clock which always returns Time, e.g. for testing or generating codes for a given time
+	FixedClock struct {
+		Time time.Time
+	}
+
+	// OffsetClock is a Clock returning the time of Clock adjusted by Offset, e.g. to correct a known clock skew
+	OffsetClock struct {
+		Clock  Clock
+		Offset time.Duration
+	}
+)
+
+// Now implements Clock
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Now implements Clock
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
+
+// Now implements Clock
+func (c OffsetClock) Now() time.Time {
+	return now(c.Clock).Add(c.Offset)
+}
+
+// now returns the time of c, or the system time when c is nil
+func now(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
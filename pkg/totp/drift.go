@@ -0,0 +1,40 @@
+package totp
+
+import "sync"
+
+type (
+	// DriftStore records the clock drift observed for each account, as the offset in time steps of its last accepted
+	// code from the current time step.
+	DriftStore interface {
+		// Drift returns the drift recorded for account, or 0 when none has been recorded
+		Drift(account string) (int, error)
+		// SetDrift records drift for account
+		SetDrift(account string, drift int) error
+	}
+
+	// MemoryDriftStore is a DriftStore held in memory
+	MemoryDriftStore struct {
+		mu    sync.Mutex
+		drift map[string]int
+	}
+)
+
+// NewMemoryDriftStore creates an empty MemoryDriftStore
+func NewMemoryDriftStore() *MemoryDriftStore {
+	return &MemoryDriftStore{drift: map[string]int{}}
+}
+
+// Drift implements DriftStore
+func (s *MemoryDriftStore) Drift(account string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drift[account], nil
+}
+
+// SetDrift implements DriftStore
+func (s *MemoryDriftStore) SetDrift(account string, drift int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drift[account] = drift
+	return nil
+}
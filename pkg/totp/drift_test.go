@@ -0,0 +1,114 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidatorDrift(t *testing.T) {
+	server := time.Unix(1111111109, 0)
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: SHA1,
+	}
+	// code returns the code shown at server time t by a prover whose clock runs slow by lag
+	code := func(at time.Time, lag time.Duration) string {
+		o := opts
+		o.Clock = FixedClock{Time: at.Add(-lag)}
+		c, err := GenerateTOTP(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	store := NewMemoryDriftStore()
+	tight := Validator{Window: Window{Behind: 1, Ahead: 1}, Drift: store, Used: NewMemoryStore()}
+
+	opts.Clock = FixedClock{Time: server}
+	if _, err := tight.Validate("a", opts, code(server, time.Minute)); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+	// a wider window establishes the drift
+	wide := Validator{Window: Window{Behind: 3, Ahead: 3}, Drift: store}
+	offset, err := wide.Validate("a", opts, code(server, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := store.Drift("a"); offset != -2 || d != -2 {
+		t.Errorf("expected offset and drift -2 got %d and %d", offset, d)
+	}
+
+	// the tight window now accepts codes from the slow prover, following further drift
+	for i, tcase := range []struct {
+		lag    time.Duration
+		offset int
+	}{
+		{time.Minute, -2},
+		{90 * time.Second, -3},
+		{90 * time.Second, -3},
+	} {
+		later := server.Add(time.Duration(i+1) * 5 * time.Minute)
+		opts.Clock = FixedClock{Time: later}
+		offset, err := tight.Validate("a", opts, code(later, tcase.lag))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d, _ := store.Drift("a"); offset != tcase.offset || d != tcase.offset {
+			t.Errorf("expected offset and drift %d got %d and %d", tcase.offset, offset, d)
+		}
+	}
+	if d, _ := store.Drift("b"); d != 0 {
+		t.Errorf("expected no drift for b got %d", d)
+	}
+}
+
+func TestValidatorReplayAcrossDrift(t *testing.T) {
+	base := time.Unix(1111111110, 0)
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: base.Add(-30 * time.Second)},
+	}
+	previous, err := GenerateTOTP(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := Validator{Window: Window{Behind: 1, Ahead: 1}, Used: NewMemoryStore(), Drift: NewMemoryDriftStore()}
+	opts.Clock = FixedClock{Time: base.Add(5 * time.Second)}
+	if offset, err := v.Validate("a", opts, previous); err != nil || offset != -1 {
+		t.Fatalf("expected offset -1 got %d %v", offset, err)
+	}
+	// the window has moved with the drift, so the code is still within it a time step later
+	opts.Clock = FixedClock{Time: base.Add(35 * time.Second)}
+	if _, err := v.Validate("a", opts, previous); err != ErrCodeReused {
+		t.Errorf("expected %v got %v", ErrCodeReused, err)
+	}
+}
+
+func TestValidatorMaxDrift(t *testing.T) {
+	server := time.Unix(1111111109, 0)
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: server.Add(-3 * 30 * time.Second)},
+	}
+	code, err := GenerateTOTP(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryDriftStore()
+	v := Validator{Window: Window{Behind: 3, Ahead: 3}, Drift: store, MaxDrift: 2}
+	opts.Clock = FixedClock{Time: server}
+	if offset, err := v.Validate("a", opts, code); err != nil || offset != -3 {
+		t.Fatalf("expected offset -3 got %d %v", offset, err)
+	}
+	if d, _ := store.Drift("a"); d != -2 {
+		t.Errorf("expected drift limited to -2 got %d", d)
+	}
+}
@@ -0,0 +1,25 @@
+package totp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+)
+
+// GenerateMOTP generates a Mobile-OTP (mOTP) code, the first Digits hexadecimal characters of the MD5 hash of the
+// time step, the secret and the PIN. The secret is used as lower case hexadecimal, as entered into mOTP clients.
+// Opts.Algorithm is not used. mOTP uses a Timestep of 10 seconds and 6 Digits.
+func GenerateMOTP(opts Opts, pin string) (string, error) {
+	if opts.Timestep == 0 {
+		return "", ErrInvalidTimestep
+	}
+	if opts.Digits < 1 || opts.Digits > 2*md5.Size {
+		return "", ErrInvalidDigits
+	}
+	step, err := opts.step()
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(strconv.FormatUint(step, 10) + hex.EncodeToString(opts.Secret) + pin))
+	return hex.EncodeToString(sum[:])[:opts.Digits], nil
+}
@@ -0,0 +1,33 @@
+package totp
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestGenerateMOTP(t *testing.T) {
+	secret, err := hex.DecodeString("e3152afee62599c8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tcase := range []struct {
+		time int64
+		code string
+	}{
+		{165892298, "e7d8b6"},
+		{123456789, "4ebfb2"},
+	} {
+		opts := Opts{Timestep: 10, Digits: 6, Secret: secret, Clock: FixedClock{Time: time.Unix(tcase.time, 0)}}
+		code, err := GenerateMOTP(opts, "1234")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tcase.code {
+			t.Errorf("expected %s got %s", tcase.code, code)
+		}
+	}
+	if _, err := GenerateMOTP(Opts{Timestep: 10, Secret: secret}, "1234"); err != ErrInvalidDigits {
+		t.Errorf("expected %v got %v", ErrInvalidDigits, err)
+	}
+}
@@ -0,0 +1,133 @@
+package totp
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Rotation is a pending change of the secret of an account. Codes from either secret are accepted until
+	// GraceUntil, after which only codes from the new secret are accepted. It holds only plain values so that it can
+	// be persisted by any RotationStore.
+	Rotation struct {
+		// the new secret
+		Secret []byte
+		// the name of the algorithm of the new secret in the registry, e.g. SHA1
+		Algorithm string
+		// the number of digits of codes from the new secret
+		Digits uint
+		// the number of seconds between codes from the new secret
+		Timestep uint
+		// the Unix time to start counting time steps from, T0
+		InitialCounterTime uint64
+		// the end of the period in which codes from the old secret are still accepted
+		GraceUntil time.Time
+		// the otpauth URI of the new secret, e.g. to show its QR code again while the Rotation is pending
+		URI string
+	}
+
+	// RotationStore records the pending Rotation of each account
+	RotationStore interface {
+		// Rotation returns the pending Rotation of account, or nil when there is none
+		Rotation(account string) (*Rotation, error)
+		// SetRotation records r as the pending Rotation of account, replacing any other
+		SetRotation(account string, r Rotation) error
+		// DeleteRotation removes the pending Rotation of account, if any
+		DeleteRotation(account string) error
+	}
+
+	// MemoryRotationStore is a RotationStore held in memory
+	MemoryRotationStore struct {
+		mu        sync.Mutex
+		rotations map[string]Rotation
+	}
+)
+
+// Opts returns the Opts of the new secret of the Rotation with clock
+func (r Rotation) Opts(clock Clock) (Opts, error) {
+	a, err := ParseAlgorithm(r.Algorithm)
+	if err != nil {
+		return Opts{}, err
+	}
+	return Opts{
+		Timestep:           r.Timestep,
+		InitialCounterTime: r.InitialCounterTime,
+		Secret:             r.Secret,
+		Digits:             r.Digits,
+		Algorithm:          a,
+		Clock:              clock,
+	}, nil
+}
+
+// NewMemoryRotationStore creates an empty MemoryRotationStore
+func NewMemoryRotationStore() *MemoryRotationStore {
+	return &MemoryRotationStore{rotations: map[string]Rotation{}}
+}
+
+// Rotation implements RotationStore
+func (s *MemoryRotationStore) Rotation(account string) (*Rotation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.rotations[account]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// SetRotation implements RotationStore
+func (s *MemoryRotationStore) SetRotation(account string, r Rotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotations[account] = r
+	return nil
+}
+
+// DeleteRotation implements RotationStore
+func (s *MemoryRotationStore) DeleteRotation(account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rotations, account)
+	return nil
+}
+
+// ValidateRotating checks code for account as Validate does, also accepting codes from the new secret of a pending
+// Rotation recorded in Rotations. Codes from the old secret, opts, are only accepted until the end of the grace
+// period. Once a code from the new secret is accepted the Rotation is complete and returned as completed. The caller
+// must store the new secret of completed in place of the old secret and then call CompleteRotation, so that the old
+// secret is no longer accepted. Without a pending Rotation, or when Rotations is nil, ValidateRotating is the same as Validate.
+func (v Validator) ValidateRotating(account string, opts Opts, code string) (offset int, completed *Rotation, err error) {
+	var r *Rotation
+	if v.Rotations != nil {
+		if r, err = v.Rotations.Rotation(account); err != nil {
+			return 0, nil, err
+		}
+	}
+	if r == nil {
+		offset, err = v.Validate(account, opts, code)
+		return offset, nil, err
+	}
+	// check both secrets at the same time
+	opts.Clock = FixedClock{Time: now(opts.Clock)}
+	next, err := r.Opts(opts.Clock)
+	if err != nil {
+		return 0, nil, err
+	}
+	offset, err = v.Validate(account, next, code)
+	if err == nil {
+		return offset, r, nil
+	}
+	if err != ErrInvalidCode || opts.Clock.Now().After(r.GraceUntil) {
+		return 0, nil, err
+	}
+	offset, err = v.Validate(account, opts, code)
+	return offset, nil, err
+}
+
+// CompleteRotation removes the pending Rotation of account once the caller has stored its new secret
+func (v Validator) CompleteRotation(account string) error {
+	if v.Rotations == nil {
+		return nil
+	}
+	return v.Rotations.DeleteRotation(account)
+}
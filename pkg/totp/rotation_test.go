@@ -0,0 +1,122 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateRotating(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	old := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: now},
+	}
+	next := old
+	next.Secret = []byte("09876543210987654321")
+	// code returns the code of opts at time at
+	code := func(opts Opts, at time.Time) string {
+		opts.Clock = FixedClock{Time: at}
+		c, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	// pending returns a Rotation to the secret of next
+	pending := func(grace time.Time, uri string) Rotation {
+		return Rotation{
+			Secret:     next.Secret,
+			Algorithm:  next.Algorithm.String(),
+			Digits:     next.Digits,
+			Timestep:   next.Timestep,
+			GraceUntil: grace,
+			URI:        uri,
+		}
+	}
+	rotations := NewMemoryRotationStore()
+	v := Validator{Window: Window{Behind: 1}, Rotations: rotations}
+
+	if _, completed, err := v.ValidateRotating("a", old, code(old, now)); err != nil || completed != nil {
+		t.Errorf("expected old code to be accepted without rotation, got %v %v", completed, err)
+	}
+	if err := rotations.SetRotation("a", pending(now.Add(time.Hour), "new")); err != nil {
+		t.Fatal(err)
+	}
+	// during the grace period codes from either secret are accepted
+	if _, completed, err := v.ValidateRotating("a", old, code(old, now)); err != nil || completed != nil {
+		t.Errorf("expected old code to be accepted without rotation, got %v %v", completed, err)
+	}
+	if _, _, err := v.ValidateRotating("a", old, "000000"); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+	offset, completed, err := v.ValidateRotating("a", old, code(next, now.Add(-30*time.Second)))
+	if err != nil || completed == nil || offset != -1 {
+		t.Fatalf("expected rotation at offset -1, got %d %v %v", offset, completed, err)
+	}
+	if !bytes.Equal(completed.Secret, next.Secret) || completed.URI != "new" {
+		t.Errorf("expected the new secret to be returned, got %v", completed)
+	}
+	// the rotation remains pending until the caller has stored the new secret
+	if r, _ := rotations.Rotation("a"); r == nil {
+		t.Error("expected rotation to be pending")
+	}
+	if err := v.CompleteRotation("a"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := rotations.Rotation("a"); r != nil {
+		t.Errorf("expected rotation to be complete, got %v", r)
+	}
+	// the completed rotation is no longer applied, so the caller must replace the old Opts
+	if _, _, err := v.ValidateRotating("a", old, code(next, now)); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+
+	// after the grace period only codes from the new secret are accepted
+	if err := rotations.SetRotation("b", pending(now.Add(-time.Second), "")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.ValidateRotating("b", old, code(old, now)); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+	if _, completed, err := v.ValidateRotating("b", old, code(next, now)); err != nil || completed == nil {
+		t.Errorf("expected rotation, got %v %v", completed, err)
+	}
+}
+
+func TestRotationJSON(t *testing.T) {
+	r := Rotation{
+		Secret:     []byte("09876543210987654321"),
+		Algorithm:  "SHA256",
+		Digits:     8,
+		Timestep:   30,
+		GraceUntil: time.Unix(1111111109, 0).UTC(),
+		URI:        "otpauth://totp/a",
+	}
+	// a Rotation can be persisted, e.g. as JSON
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Rotation
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, r) {
+		t.Errorf("expected %+v got %+v", r, decoded)
+	}
+	opts, err := decoded.Opts(nil)
+	if err != nil || opts.Algorithm != SHA256 || opts.Digits != 8 {
+		t.Errorf("unexpected opts %+v %v", opts, err)
+	}
+	decoded.Algorithm = "SHA0"
+	if _, err := decoded.Opts(nil); !errors.Is(err, ErrInvalidAlgorithm) {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+}
@@ -0,0 +1,95 @@
+package totp
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/richardjennings/totp/internal/atomicfile"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrCodeReused is returned when a code is presented for a time step which has already been accepted
+var ErrCodeReused = errors.New("code already used")
+
+type (
+	// UsedCodeStore records the last accepted time step per account so that a code is never accepted twice.
+	UsedCodeStore interface {
+		// Use records step as the last accepted time step for account at the time now. ErrCodeReused is returned if
+		// step is not after the last time step recorded for account. The record is not needed after expiry and may be
+		// discarded once now is after expiry. now is the time of validation, so that expiry follows the same clock.
+		Use(now time.Time, account string, step uint64, expiry time.Time) error
+	}
+
+	// MemoryStore is a UsedCodeStore held in memory. Records are discarded once they expire.
+	MemoryStore struct {
+		mu   sync.Mutex
+		used map[string]usedStep
+	}
+
+	// FileStore is a UsedCodeStore persisted as JSON to a file, so that used codes are remembered across restarts.
+	FileStore struct {
+		mu   sync.Mutex
+		path string
+	}
+
+	usedStep struct {
+		Step   uint64    `json:"step"`
+		Expiry time.Time `json:"expiry"`
+	}
+)
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{used: map[string]usedStep{}}
+}
+
+// Use implements UsedCodeStore
+func (s *MemoryStore) Use(now time.Time, account string, step uint64, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return use(now, s.used, account, step, expiry)
+}
+
+// NewFileStore creates a FileStore persisting to path. The file is created on first use.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Use implements UsedCodeStore
+func (s *FileStore) Use(now time.Time, account string, step uint64, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	used := map[string]usedStep{}
+	b, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &used); err != nil {
+			return err
+		}
+	}
+	if err := use(now, used, account, step, expiry); err != nil {
+		return err
+	}
+	b, err = json.Marshal(used)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.path, b)
+}
+
+// use discards records which have expired by t from used and then records step for account
+func use(t time.Time, used map[string]usedStep, account string, step uint64, expiry time.Time) error {
+	for k, v := range used {
+		if t.After(v.Expiry) {
+			delete(used, k)
+		}
+	}
+	if v, ok := used[account]; ok && step <= v.Step {
+		return ErrCodeReused
+	}
+	used[account] = usedStep{Step: step, Expiry: expiry}
+	return nil
+}
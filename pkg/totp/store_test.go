@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidatorReplay(t *testing.T) {
+	memory := NewMemoryStore()
+	file := NewFileStore(filepath.Join(t.TempDir(), "used.json"))
+	for name, store := range map[string]UsedCodeStore{"memory": memory, "file": file} {
+		v := Validator{Window: Window{Behind: 1, Ahead: 1}, Used: store}
+		clock := FixedClock{Time: time.Unix(1111111109, 0)}
+		opts := Opts{
+			Timestep:  30,
+			Secret:    []byte("12345678901234567890"),
+			Digits:    6,
+			Algorithm: SHA1,
+			Clock:     OffsetClock{Clock: clock, Offset: -30 * time.Second},
+		}
+		previous, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Clock = clock
+		current, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := v.Validate("a", opts, current); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if _, err := v.Validate("a", opts, current); err != ErrCodeReused {
+			t.Errorf("%s: expected %v got %v", name, ErrCodeReused, err)
+		}
+		// an earlier time step must not be accepted after a later one
+		if _, err := v.Validate("a", opts, previous); err != ErrCodeReused {
+			t.Errorf("%s: expected %v got %v", name, ErrCodeReused, err)
+		}
+		// accounts are tracked independently
+		if _, err := v.Validate("b", opts, current); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Unix(1111111109, 0)
+	if err := s.Use(now, "a", 1, now.Add(-time.Second)); err != nil {
+		t.Error(err)
+	}
+	if err := s.Use(now, "a", 1, now.Add(time.Minute)); err != nil {
+		t.Errorf("expected expired record to be discarded, got %v", err)
+	}
+	if err := s.Use(now, "a", 1, now.Add(time.Minute)); err != ErrCodeReused {
+		t.Errorf("expected %v got %v", ErrCodeReused, err)
+	}
+}
+
+func TestValidatorReplayFixedClock(t *testing.T) {
+	// records expire by the time of validation, not the system time, so a code for a time step in the past is still
+	// rejected when replayed at that time
+	v := Validator{Window: Window{Behind: 1, Ahead: 1}, Used: NewMemoryStore()}
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(1111111109, 0)},
+	}
+	code, err := GenerateTOTP(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Validate("a", opts, code); err != nil {
+		t.Fatal(err)
+	}
+	opts.Clock = FixedClock{Time: time.Unix(1111111109+30, 0)}
+	if _, err := v.Validate("a", opts, code); err != ErrCodeReused {
+		t.Errorf("expected %v got %v", ErrCodeReused, err)
+	}
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"context"
+	"time"
+)
+
+// Tick is a TOTP and the period during which it is valid
+type Tick struct {
+	Code       string
+	ValidFrom  time.Time
+	ValidUntil time.Time
+}
+
+// Ticker returns a channel on which a Tick is sent for the current time step and then for each following time step as
+// it begins. Each wait is calculated from opts.Clock at the time so that ticks stay aligned to time step boundaries
+// over long runs. The channel is closed when ctx is cancelled, or if the time steps can no longer be calculated.
+func Ticker(ctx context.Context, opts Opts) (<-chan Tick, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := opts.step(); err != nil {
+		return nil, err
+	}
+	g, err := opts.generator()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Tick)
+	go func() {
+		defer close(ch)
+		var last uint64
+		first := true
+		for {
+			step, err := opts.step()
+			if err != nil {
+				return
+			}
+			until := time.Unix(int64(opts.stepTime(step+1)), 0)
+			// a timer may fire marginally before the boundary, in which case wait again rather than repeat the tick
+			if first || step != last {
+				code, err := g.Generate(step)
+				if err != nil {
+					return
+				}
+				t := Tick{Code: code, ValidFrom: time.Unix(int64(opts.stepTime(step)), 0), ValidUntil: until}
+				select {
+				case ch <- t:
+				case <-ctx.Done():
+					return
+				}
+				first = false
+				last = step
+			}
+			timer := time.NewTimer(until.Sub(now(opts.Clock)))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
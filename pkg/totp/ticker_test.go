@@ -0,0 +1,50 @@
+package totp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTicker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := Opts{
+		Timestep:  1,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: SHA1,
+	}
+	ch, err := Ticker(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev Tick
+	for i := 0; i < 3; i++ {
+		tick := <-ch
+		if i > 0 && !tick.ValidFrom.Equal(prev.ValidUntil) {
+			t.Errorf("expected tick from %s got %s", prev.ValidUntil, tick.ValidFrom)
+		}
+		if tick.ValidUntil.Sub(tick.ValidFrom) != time.Second {
+			t.Errorf("expected a period of 1s got %s", tick.ValidUntil.Sub(tick.ValidFrom))
+		}
+		opts.Clock = FixedClock{Time: tick.ValidFrom}
+		code, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tick.Code != code {
+			t.Errorf("expected %s got %s", code, tick.Code)
+		}
+		prev = tick
+	}
+	cancel()
+	for range ch {
+	}
+}
+
+func TestTickerInvalid(t *testing.T) {
+	if _, err := Ticker(context.Background(), Opts{Digits: 6, Algorithm: SHA1}); err != ErrInvalidTimestep {
+		t.Errorf("expected %v got %v", ErrInvalidTimestep, err)
+	}
+}
@@ -1,17 +1,17 @@
 package totp
 
 import (
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
 	"encoding/base32"
-	"fmt"
+	"errors"
 	"github.com/richardjennings/totp/pkg/hotp"
 	"hash"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Algo identifies a hash algorithm in the registry of algorithms
 type Algo int
 
 const (
@@ -19,50 +19,131 @@ const (
 	SHA1
 	SHA256
 	SHA512
+	// MD5 is used by legacy tokens and must be enabled with EnableAlgorithm. Its 16 byte HMAC is too short for the
+	// dynamic truncation of HOTP and TOTP, which fail with hotp.ErrInvalidHash
+	MD5
+	// SHA3_256 must be enabled with EnableAlgorithm
+	SHA3_256
+	// SHA3_512 must be enabled with EnableAlgorithm
+	SHA3_512
+)
+
+var (
+	// ErrInvalidTimestep is returned when Opts.Timestep is zero
+	ErrInvalidTimestep = errors.New("timestep must be greater than zero")
+	// ErrInvalidAlgorithm is returned when an algorithm is not registered or not enabled
+	ErrInvalidAlgorithm = errors.New("invalid algorithm")
+	// ErrInvalidDigits is returned when Opts.Digits is not between 1 and 9
+	ErrInvalidDigits = hotp.ErrInvalidDigits
+	// ErrBeforeT0 is returned when the current time is before Opts.InitialCounterTime
+	ErrBeforeT0 = errors.New("time is before the initial counter time")
 )
 
 type Opts struct {
 	// the number of seconds between generating TOTPs. A default timestep of 30 seconds is recommended
 	Timestep uint
+	// the Unix time to start counting time steps from, T0. The default of 0 is the Unix epoch
+	InitialCounterTime uint64
 	// The shared secret
 	Secret []byte
-	// Number of Digits required
+	// Number of Digits required, or of symbols when Encoder is not Decimal
 	Digits uint
 	// the algorithm to use
 	Algorithm Algo
-	// For testing purposes, the current Unix time
-	CurrentUnixTime uint64
+	// provides the current time. The system time is used when nil
+	Clock Clock
+	// renders codes, e.g. hotp.Steam. Decimal digits are used when nil
+	Encoder hotp.Encoder
+	// generates codes in place of creating a hotp.Generator on each use, e.g. one kept per account by the caller. It
+	// must have been created from Secret, Algorithm, Digits and Encoder. A new Generator is created when nil
+	Generator *hotp.Generator
 }
 
+// Algo returns the hash function of Algorithm, or nil when it is not registered or not enabled
 func (o Opts) Algo() func() hash.Hash {
-	switch o.Algorithm {
-	case SHA1:
-		return func() hash.Hash { return sha1.New() }
-	case SHA256:
-		return func() hash.Hash { return sha256.New() }
-	case SHA512:
-		return func() hash.Hash { return sha512.New() }
-	default:
-		return nil
+	return o.Algorithm.Hash()
+}
+
+// Validate returns an error describing why Opts cannot be used to generate a TOTP, or nil when they can
+func (o Opts) Validate() error {
+	if o.Timestep == 0 {
+		return ErrInvalidTimestep
+	}
+	if o.Algo() == nil {
+		return ErrInvalidAlgorithm
+	}
+	if o.Digits < 1 || o.Digits > 9 {
+		return ErrInvalidDigits
+	}
+	return nil
+}
+
+// step returns the number of time steps since T0 at the current time
+func (o Opts) step() (uint64, error) {
+	return o.stepAt(now(o.Clock))
+}
+
+// stepAt returns the number of time steps since T0 at time tm
+func (o Opts) stepAt(tm time.Time) (uint64, error) {
+	t := tm.Unix()
+	if t < 0 || uint64(t) < o.InitialCounterTime {
+		return 0, ErrBeforeT0
+	}
+	return (uint64(t) - o.InitialCounterTime) / uint64(o.Timestep), nil
+}
+
+// stepTime returns the Unix time at which a time step starts
+func (o Opts) stepTime(step uint64) uint64 {
+	return o.InitialCounterTime + step*uint64(o.Timestep)
+}
+
+// generate returns the code for a time step
+func (o Opts) generate(step uint64) (string, error) {
+	if o.Generator != nil {
+		return o.Generator.Generate(step)
+	}
+	return hotp.GenerateHOTPEncoded(o.Algo(), o.Secret, step, o.Digits, o.Encoder)
+}
+
+// generator returns a hotp.Generator for generating the codes of many time steps, Opts.Generator when it is set
+func (o Opts) generator() (*hotp.Generator, error) {
+	if o.Generator != nil {
+		return o.Generator, nil
+	}
+	return hotp.NewGenerator(o.Algo(), o.Secret, o.Digits, o.Encoder)
+}
+
+// NewGenerator returns a hotp.Generator for the codes of Opts, which can be set as Opts.Generator and reused
+func (o Opts) NewGenerator() (*hotp.Generator, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
 	}
+	return hotp.NewGenerator(o.Algo(), o.Secret, o.Digits, o.Encoder)
 }
 
 // Generate a TOTP
-func GenerateTOTP(opts Opts) (code string) {
-	t := opts.CurrentUnixTime
+func GenerateTOTP(opts Opts) (code string, err error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
 	// calculate number of timesteps
-	steps := t / uint64(opts.Timestep)
+	steps, err := opts.step()
+	if err != nil {
+		return "", err
+	}
 
-	return hotp.GenerateHOTP(opts.Algo(), opts.Secret, steps, opts.Digits)
+	return opts.generate(steps)
 }
 
-func GenerateTOTPFromOTPAuth(otpAuth string, timestamp string) (label string, code string, err error) {
+// GenerateTOTPFromOTPAuth generates a TOTP from an otpauth:// string at the time of clock, or the system time when
+// clock is nil
+func GenerateTOTPFromOTPAuth(otpAuth string, clock Clock) (label string, code string, err error) {
 	var u *url.URL
 	var digits int
 	var period int
 	var secret []byte
 	var algorithm Algo
-	var t int
+	var t0 uint64
 
 	u, err = url.Parse(otpAuth)
 	if err != nil {
@@ -91,6 +172,13 @@ func GenerateTOTPFromOTPAuth(otpAuth string, timestamp string) (label string, co
 		}
 	}
 
+	if v := c.Get("t0"); v != "" {
+		t0, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return
+		}
+	}
+
 	secret, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(c.Get("secret"))
 	if err != nil {
 		return
@@ -99,38 +187,25 @@ func GenerateTOTPFromOTPAuth(otpAuth string, timestamp string) (label string, co
 	a := c.Get("algorithm")
 	if a == "" {
 		algorithm = SHA1
-	} else {
-		switch a {
-		case "SHA1":
-			algorithm = SHA1
-		case "SHA256":
-			algorithm = SHA256
-		case "SHA512":
-			algorithm = SHA512
-		default:
-			err = fmt.Errorf("invalid algo %s", a)
-			return
-		}
+	} else if algorithm, err = ParseAlgorithm(a); err != nil {
+		return
 	}
 
 	opts := Opts{
-		Timestep:  uint(period),
-		Secret:    secret,
-		Digits:    uint(digits),
-		Algorithm: algorithm,
+		Timestep:           uint(period),
+		InitialCounterTime: t0,
+		Secret:             secret,
+		Digits:             uint(digits),
+		Algorithm:          algorithm,
+		Clock:              clock,
 	}
 
-	if timestamp != "" {
-		t, err = strconv.Atoi(timestamp)
-		if err != nil {
-			return
-		}
-		opts.CurrentUnixTime = uint64(t)
+	code, err = GenerateTOTP(opts)
+	if err != nil {
+		return
 	}
 
-	code = GenerateTOTP(opts)
-
-	label = u.Path[1:]
+	label = strings.TrimPrefix(u.Path, "/")
 
 	return
 }
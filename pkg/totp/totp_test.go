@@ -2,6 +2,7 @@ package totp
 
 import (
 	"testing"
+	"time"
 )
 
 /*
@@ -62,16 +63,16 @@ func TestGenerateTOTP(t *testing.T) {
 	sha256Secret := []byte("12345678901234567890123456789012")
 	sha512Secret := []byte("1234567890123456789012345678901234567890123456789012345678901234")
 	opts := Opts{
-		Timestep:        30,
-		Secret:          sha1Secret,
-		Digits:          8,
-		Algorithm:       SHA1,
-		CurrentUnixTime: 59,
+		Timestep:  30,
+		Secret:    sha1Secret,
+		Digits:    8,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(59, 0)},
 	}
 	for _, tcase := range []struct {
 		mode   Algo
 		secret []byte
-		time   uint64
+		time   int64
 		totp   string
 	}{
 		{SHA1, sha1Secret, 59, "94287082"},
@@ -95,10 +96,82 @@ func TestGenerateTOTP(t *testing.T) {
 	} {
 		opts.Algorithm = tcase.mode
 		opts.Secret = tcase.secret
-		opts.CurrentUnixTime = tcase.time
-		totp := GenerateTOTP(opts)
+		opts.Clock = FixedClock{Time: time.Unix(tcase.time, 0)}
+		totp, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Error(err)
+		}
 		if totp != tcase.totp {
 			t.Errorf("expected %s got %s", tcase.totp, totp)
 		}
 	}
 }
+
+func TestOptsValidate(t *testing.T) {
+	for _, tcase := range []struct {
+		opts Opts
+		err  error
+	}{
+		{Opts{Timestep: 30, Digits: 6, Algorithm: SHA1}, nil},
+		{Opts{Timestep: 0, Digits: 6, Algorithm: SHA1}, ErrInvalidTimestep},
+		{Opts{Timestep: 30, Digits: 6, Algorithm: Invalid}, ErrInvalidAlgorithm},
+		{Opts{Timestep: 30, Digits: 6, Algorithm: Algo(42)}, ErrInvalidAlgorithm},
+		{Opts{Timestep: 30, Digits: 0, Algorithm: SHA1}, ErrInvalidDigits},
+		{Opts{Timestep: 30, Digits: 10, Algorithm: SHA1}, ErrInvalidDigits},
+	} {
+		if err := tcase.opts.Validate(); err != tcase.err {
+			t.Errorf("expected %v got %v", tcase.err, err)
+		}
+		if _, err := GenerateTOTP(tcase.opts); err != tcase.err {
+			t.Errorf("expected %v got %v", tcase.err, err)
+		}
+	}
+}
+
+func TestGenerateTOTPInitialCounterTime(t *testing.T) {
+	opts := Opts{
+		Timestep:           30,
+		InitialCounterTime: 1000000000,
+		Secret:             []byte("12345678901234567890"),
+		Digits:             8,
+		Algorithm:          SHA1,
+		Clock:              FixedClock{Time: time.Unix(1000000059, 0)},
+	}
+	// one time step after T0 produces the same code as one time step after the Unix epoch
+	totp, err := GenerateTOTP(opts)
+	if err != nil {
+		t.Error(err)
+	}
+	if totp != "94287082" {
+		t.Errorf("expected 94287082 got %s", totp)
+	}
+	if _, err := Validate(opts, totp, Window{}); err != nil {
+		t.Error(err)
+	}
+	opts.Clock = FixedClock{Time: time.Unix(999999999, 0)}
+	if _, err := GenerateTOTP(opts); err != ErrBeforeT0 {
+		t.Errorf("expected %v got %v", ErrBeforeT0, err)
+	}
+	if _, err := Validate(opts, totp, Window{Ahead: 1}); err != ErrBeforeT0 {
+		t.Errorf("expected %v got %v", ErrBeforeT0, err)
+	}
+}
+
+func TestGenerateTOTPFromOTPAuth(t *testing.T) {
+	clock := FixedClock{Time: time.Unix(59, 0)}
+	for _, tcase := range []struct {
+		otpAuth string
+		label   string
+	}{
+		{otpAuth: "otpauth://totp/me@myorg?digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", label: "me@myorg"},
+		{otpAuth: "otpauth://totp?digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", label: ""},
+	} {
+		label, code, err := GenerateTOTPFromOTPAuth(tcase.otpAuth, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if label != tcase.label || code != "94287082" {
+			t.Errorf("expected %s 94287082 got %s %s", tcase.label, label, code)
+		}
+	}
+}
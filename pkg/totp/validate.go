@@ -0,0 +1,149 @@
+package totp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCode is returned when a code does not match any TOTP within the validation window
+var ErrInvalidCode = errors.New("invalid code")
+
+// DefaultMaxDrift is the largest drift in time steps recorded by a Validator when Validator.MaxDrift is 0
+const DefaultMaxDrift = 10
+
+// Window is the number of time steps before and after the current time step for which a code is accepted.
+// RFC 6238 section 5.2 recommends allowing at most one time step of network delay.
+type Window struct {
+	// the number of time steps before the current time step to check
+	Behind uint
+	// the number of time steps after the current time step to check
+	Ahead uint
+}
+
+// Validate checks code against the TOTPs for each time step within the window around the current time step.
+// On success offset is the position of the matching time step relative to the current time step, e.g. -1 when the
+// code was generated for the previous time step. Every time step in the window is compared in constant time so that
+// the time taken does not reveal which, if any, time step matched.
+func Validate(opts Opts, code string, window Window) (offset int, err error) {
+	return ValidateWithDrift(opts, code, window, 0)
+}
+
+// ValidateWithDrift checks code as Validate does, with the window centred on the current time step adjusted by drift
+// time steps, e.g. -2 for a prover whose clock is known to run a minute slow with a 30 second time step. This allows
+// a tight window to be used for provers with a known drift as recommended by RFC 6238 section 6. The returned offset
+// is relative to the current time step, not to the drift, so that it can be stored as the drift for the next
+// validation.
+func ValidateWithDrift(opts Opts, code string, window Window, drift int) (offset int, err error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+	current, err := opts.step()
+	if err != nil {
+		return 0, err
+	}
+	g, err := opts.generator()
+	if err != nil {
+		return 0, err
+	}
+	found := false
+	for i := drift - int(window.Behind); i <= drift+int(window.Ahead); i++ {
+		if i < 0 && uint64(-i) > current {
+			// time steps before T0 do not exist
+			continue
+		}
+		step := uint64(int64(current) + int64(i))
+		c, err := g.Generate(step)
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(c), []byte(code)) == 1 && !found {
+			found = true
+			offset = i
+		}
+	}
+	if !found {
+		return 0, ErrInvalidCode
+	}
+	return offset, nil
+}
+
+// Validator validates codes for accounts, rejecting codes which have already been accepted when Used is set and
+// tracking the clock drift of each account when Drift is set.
+type Validator struct {
+	// the window of time steps around the current time step, adjusted by any drift, in which codes are accepted
+	Window Window
+	// records the last accepted time step per account. Codes are not checked for reuse when nil
+	Used UsedCodeStore
+	// records the drift per account. Drift is not tracked when nil
+	Drift DriftStore
+	// the largest drift in time steps recorded, ahead or behind. DefaultMaxDrift is used when 0
+	MaxDrift uint
+	// records the pending secret Rotation per account for ValidateRotating. Secrets are not rotated when nil
+	Rotations RotationStore
+}
+
+// Validate checks code for account as ValidateWithDrift does. When a DriftStore is configured the drift recorded for
+// account is applied, and the offset of an accepted code, limited to MaxDrift, is recorded as the new drift. When a
+// UsedCodeStore is configured, the matching time step is recorded and a code for the same or an earlier time step is
+// rejected with ErrCodeReused as required by RFC 6238 section 5.2.
+func (v Validator) Validate(account string, opts Opts, code string) (offset int, err error) {
+	// use the same time throughout
+	opts.Clock = FixedClock{Time: now(opts.Clock)}
+	drift := 0
+	if v.Drift != nil {
+		if drift, err = v.Drift.Drift(account); err != nil {
+			return 0, err
+		}
+	}
+	offset, err = ValidateWithDrift(opts, code, v.Window, drift)
+	if err != nil {
+		return 0, err
+	}
+	next := drift
+	if v.Drift != nil {
+		next = v.clampDrift(offset)
+	}
+	if v.Used != nil {
+		current, err := opts.step()
+		if err != nil {
+			return 0, err
+		}
+		step := uint64(int64(current) + int64(offset))
+		// the time step can no longer be matched once it has fallen behind the window, which is centred on the
+		// current drift now and on the new drift from now on, so the record must outlast both
+		oldest := drift
+		if next < oldest {
+			oldest = next
+		}
+		end := int64(step) - int64(oldest) + int64(v.Window.Behind) + 1
+		if end < 0 {
+			end = 0
+		}
+		expiry := time.Unix(int64(opts.stepTime(uint64(end))), 0)
+		if err = v.Used.Use(opts.Clock.Now(), account, step, expiry); err != nil {
+			return 0, err
+		}
+	}
+	if v.Drift != nil && next != drift {
+		if err := v.Drift.SetDrift(account, next); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// clampDrift limits drift to MaxDrift time steps ahead or behind
+func (v Validator) clampDrift(drift int) int {
+	limit := int(v.MaxDrift)
+	if limit == 0 {
+		limit = DefaultMaxDrift
+	}
+	if drift > limit {
+		return limit
+	}
+	if drift < -limit {
+		return -limit
+	}
+	return drift
+}
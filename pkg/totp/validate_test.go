@@ -0,0 +1,118 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(1111111109, 0)},
+	}
+	window := Window{Behind: 1, Ahead: 1}
+	for _, tcase := range []struct {
+		time   int64
+		offset int
+		err    error
+	}{
+		{1111111109, 0, nil},
+		{1111111109 - 30, -1, nil},
+		{1111111109 + 30, 1, nil},
+		{1111111109 - 60, 0, ErrInvalidCode},
+		{1111111109 + 60, 0, ErrInvalidCode},
+	} {
+		opts.Clock = FixedClock{Time: time.Unix(tcase.time, 0)}
+		code, err := GenerateTOTP(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Clock = FixedClock{Time: time.Unix(1111111109, 0)}
+		offset, err := Validate(opts, code, window)
+		if err != tcase.err {
+			t.Errorf("expected error %v got %v", tcase.err, err)
+		}
+		if offset != tcase.offset {
+			t.Errorf("expected offset %d got %d", tcase.offset, offset)
+		}
+	}
+}
+
+func TestValidateBeforeEpoch(t *testing.T) {
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(59, 0)},
+	}
+	offset, err := Validate(opts, "94287082", Window{Behind: 5, Ahead: 0})
+	if err != nil {
+		t.Error(err)
+	}
+	if offset != 0 {
+		t.Errorf("expected offset 0 got %d", offset)
+	}
+	if _, err := Validate(opts, "00000000", Window{Behind: 5, Ahead: 0}); err != ErrInvalidCode {
+		t.Errorf("expected %v got %v", ErrInvalidCode, err)
+	}
+}
+
+func TestValidateGenerator(t *testing.T) {
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(1111111109, 0)},
+	}
+	g, err := opts.NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Generator = g
+	if code, err := GenerateTOTP(opts); err != nil || code != "07081804" {
+		t.Errorf("expected 07081804 got %s %v", code, err)
+	}
+	v := Validator{Window: Window{Behind: 1, Ahead: 1}}
+	if offset, err := v.Validate("a", opts, "14050471"); err != nil || offset != 1 {
+		t.Errorf("expected offset 1 got %d %v", offset, err)
+	}
+	opts.Algorithm = Invalid
+	if _, err := opts.NewGenerator(); err != ErrInvalidAlgorithm {
+		t.Errorf("expected %v got %v", ErrInvalidAlgorithm, err)
+	}
+}
+
+func BenchmarkValidatorValidate(b *testing.B) {
+	opts := Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    8,
+		Algorithm: SHA1,
+		Clock:     FixedClock{Time: time.Unix(1111111109, 0)},
+	}
+	v := Validator{Window: Window{Behind: 1, Ahead: 1}}
+	validate := func(b *testing.B, opts Opts) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := v.Validate("a", opts, "07081804"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("NewGenerator", func(b *testing.B) {
+		validate(b, opts)
+	})
+	b.Run("Generator", func(b *testing.B) {
+		g, err := opts.NewGenerator()
+		if err != nil {
+			b.Fatal(err)
+		}
+		opts.Generator = g
+		validate(b, opts)
+	})
+}
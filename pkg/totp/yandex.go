@@ -0,0 +1,59 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// yandexSecretLength is the length of a Yandex Key secret. Secrets may be followed by a further 10 bytes, which
+	// are not used
+	yandexSecretLength = 16
+	// yandexMaxDigits is the most letters of a Yandex Key code which are determined by the 63-bit truncated value
+	yandexMaxDigits = 13
+)
+
+// GenerateYandex generates a Yandex Key code. The HMAC-SHA256 key is the SHA256 hash of the PIN followed by the
+// secret, without a leading zero byte, and the code is 63 bits of the HMAC rendered as Digits lower case letters.
+// Opts.Algorithm is not used. Yandex Key uses a Timestep of 30 seconds and 8 Digits.
+func GenerateYandex(opts Opts, pin string) (string, error) {
+	if opts.Timestep == 0 {
+		return "", ErrInvalidTimestep
+	}
+	if opts.Digits < 1 || opts.Digits > yandexMaxDigits {
+		return "", ErrInvalidDigits
+	}
+	step, err := opts.step()
+	if err != nil {
+		return "", err
+	}
+	secret := opts.Secret
+	if len(secret) > yandexSecretLength {
+		secret = secret[:yandexSecretLength]
+	}
+	key := sha256.Sum256(append([]byte(pin), secret...))
+	k := key[:]
+	if k[0] == 0 {
+		k = k[1:]
+	}
+	h := hmac.New(sha256.New, k)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], step)
+	h.Write(counter[:])
+	sum := h.Sum(nil)
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint64(sum[offset:offset+8]) & 0x7fffffffffffffff
+
+	mod := uint64(1)
+	for i := uint(0); i < opts.Digits; i++ {
+		mod *= 26
+	}
+	value %= mod
+	code := make([]byte, opts.Digits)
+	for i := len(code) - 1; i >= 0; i-- {
+		code[i] = byte('a' + value%26)
+		value /= 26
+	}
+	return string(code), nil
+}
@@ -0,0 +1,38 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestGenerateYandex(t *testing.T) {
+	for _, tcase := range []struct {
+		pin    string
+		secret string
+		time   int64
+		code   string
+	}{
+		{"5239", "6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY", 1641559648, "umozdicq"},
+		{"7586", "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", 1581064020, "oactmacq"},
+		{"7586", "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", 1581090810, "wemdwrix"},
+		{"5210481216086702", "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", 1581091469, "dfrpywob"},
+		{"5210481216086702", "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", 1581093059, "vunyprpd"},
+	} {
+		secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(tcase.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Opts{Timestep: 30, Digits: 8, Secret: secret, Clock: FixedClock{Time: time.Unix(tcase.time, 0)}}
+		code, err := GenerateYandex(opts, tcase.pin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tcase.code {
+			t.Errorf("expected %s got %s", tcase.code, code)
+		}
+	}
+	if _, err := GenerateYandex(Opts{Timestep: 30, Digits: 14}, "1234"); err != ErrInvalidDigits {
+		t.Errorf("expected %v got %v", ErrInvalidDigits, err)
+	}
+}
@@ -0,0 +1,239 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/richardjennings/totp/internal/atomicfile"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"golang.org/x/crypto/scrypt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	version = 1
+	// scrypt parameters recommended for interactive logins
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	fileMode     = 0600
+	dirFileMode  = 0700
+	kdfAlgorithm = "scrypt"
+)
+
+var (
+	// ErrDecrypt is returned when a vault cannot be decrypted, either because the passphrase is wrong or the file has
+	// been modified
+	ErrDecrypt = errors.New("incorrect passphrase or corrupt vault")
+	// ErrNotFound is returned when no account exists with a name
+	ErrNotFound = errors.New("account not found")
+	// ErrExists is returned when an account already exists with a name
+	ErrExists = errors.New("account already exists")
+)
+
+type (
+	// Vault is a set of named accounts stored in a single file, encrypted with AES-256-GCM using a key derived from a
+	// passphrase with scrypt. Changes are only written to the file by Save.
+	Vault struct {
+		path       string
+		passphrase []byte
+		entries    map[string]otpauth.AuthURI
+	}
+
+	// Entry is a named account in a Vault
+	Entry struct {
+		Name string
+		URI  otpauth.AuthURI
+	}
+
+	// file is the encrypted representation of a Vault on disk
+	file struct {
+		Version int    `json:"version"`
+		KDF     string `json:"kdf"`
+		N       int    `json:"n"`
+		R       int    `json:"r"`
+		P       int    `json:"p"`
+		Salt    []byte `json:"salt"`
+		Nonce   []byte `json:"nonce"`
+		Data    []byte `json:"data"`
+	}
+
+	// entry is the plaintext representation of an Entry
+	entry struct {
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+)
+
+// Create creates an empty Vault at path encrypted with passphrase. An error is returned if the file already exists.
+func Create(path string, passphrase []byte) (*Vault, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("vault %s already exists", path)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	v := &Vault{path: path, passphrase: passphrase, entries: map[string]otpauth.AuthURI{}}
+	return v, v.Save()
+}
+
+// Open decrypts the Vault at path with passphrase
+func Open(path string, passphrase []byte) (*Vault, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.Version != version || f.KDF != kdfAlgorithm {
+		return nil, fmt.Errorf("unsupported vault version %d %s", f.Version, f.KDF)
+	}
+	// only the parameters written by Save are accepted, so that a modified file cannot demand excessive memory or time
+	if f.N != scryptN || f.R != scryptR || f.P != scryptP {
+		return nil, ErrDecrypt
+	}
+	aead, err := newAEAD(passphrase, f.Salt, f.N, f.R, f.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	var entries []entry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, err
+	}
+	v := &Vault{path: path, passphrase: passphrase, entries: map[string]otpauth.AuthURI{}}
+	for _, e := range entries {
+		uri, err := otpauth.AuthURIFromString(e.URI)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", e.Name, err)
+		}
+		v.entries[e.Name] = uri
+	}
+	return v, nil
+}
+
+// Save encrypts the Vault and writes it to its file. A new salt and nonce are used each time.
+func (v *Vault) Save() error {
+	var entries []entry
+	for _, e := range v.List() {
+		entries = append(entries, entry{Name: e.Name, URI: e.URI.URL().String()})
+	}
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	f := file{Version: version, KDF: kdfAlgorithm, N: scryptN, R: scryptR, P: scryptP, Salt: make([]byte, saltLength)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(v.passphrase, f.Salt, f.N, f.R, f.P)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plain, nil)
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), dirFileMode); err != nil {
+		return err
+	}
+	return atomicfile.Write(v.path, b)
+}
+
+// Add adds an account named name
+func (v *Vault) Add(name string, uri otpauth.AuthURI) error {
+	if name == "" {
+		return errors.New("name required")
+	}
+	if _, ok := v.entries[name]; ok {
+		return ErrExists
+	}
+	v.entries[name] = uri
+	return nil
+}
+
+// Get returns the account named name
+func (v *Vault) Get(name string) (otpauth.AuthURI, error) {
+	uri, ok := v.entries[name]
+	if !ok {
+		return uri, ErrNotFound
+	}
+	return uri, nil
+}
+
+// Set replaces the account named name, e.g. to store an updated HOTP counter
+func (v *Vault) Set(name string, uri otpauth.AuthURI) error {
+	if _, ok := v.entries[name]; !ok {
+		return ErrNotFound
+	}
+	v.entries[name] = uri
+	return nil
+}
+
+// List returns the accounts ordered by name
+func (v *Vault) List() []Entry {
+	var entries []Entry
+	for k, uri := range v.entries {
+		entries = append(entries, Entry{Name: k, URI: uri})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Remove removes the account named name
+func (v *Vault) Remove(name string) error {
+	if _, ok := v.entries[name]; !ok {
+		return ErrNotFound
+	}
+	delete(v.entries, name)
+	return nil
+}
+
+// Rename renames the account named from to to
+func (v *Vault) Rename(from string, to string) error {
+	uri, ok := v.entries[from]
+	if !ok {
+		return ErrNotFound
+	}
+	if to == "" {
+		return errors.New("name required")
+	}
+	if _, ok := v.entries[to]; ok {
+		return ErrExists
+	}
+	delete(v.entries, from)
+	v.entries[to] = uri
+	return nil
+}
+
+// newAEAD derives a key from passphrase and returns an AES-256-GCM AEAD using it
+func newAEAD(passphrase []byte, salt []byte, n int, r int, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"encoding/json"
+	"github.com/richardjennings/totp/pkg/otpauth"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	passphrase := []byte("correct horse battery staple")
+	uri, err := otpauth.NewAuthURI("totp@myorg", "SHA1", 6, "myorg", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Create(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(path, passphrase); err == nil {
+		t.Error("expected error creating existing vault")
+	}
+	if err := v.Add("github", uri); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Add("github", uri); err != ErrExists {
+		t.Errorf("expected %v got %v", ErrExists, err)
+	}
+	if err := v.Add("gitlab", uri); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Rename("gitlab", "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, []byte("wrong")); err != ErrDecrypt {
+		t.Errorf("expected %v got %v", ErrDecrypt, err)
+	}
+	v, err = Open(path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := v.List()
+	if len(entries) != 2 || entries[0].Name != "github" || entries[1].Name != "work" {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	if entries[0].URI.URL().String() != uri.URL().String() {
+		t.Errorf("expected %s got %s", uri.URL(), entries[0].URI.URL())
+	}
+	if err := v.Remove("github"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Get("github"); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+	if err := v.Remove("github"); err != ErrNotFound {
+		t.Errorf("expected %v got %v", ErrNotFound, err)
+	}
+}
+
+func TestOpenScryptParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	passphrase := []byte("correct horse battery staple")
+	if _, err := Create(path, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tcase := range []struct{ n, r, p int }{{1 << 30, scryptR, scryptP}, {scryptN, 1 << 20, scryptP}, {scryptN, scryptR, 1 << 20}, {0, 0, 0}} {
+		var f file
+		if err := json.Unmarshal(b, &f); err != nil {
+			t.Fatal(err)
+		}
+		f.N, f.R, f.P = tcase.n, tcase.r, tcase.p
+		modified, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, modified, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path, passphrase); err != ErrDecrypt {
+			t.Errorf("%+v: expected %v got %v", tcase, ErrDecrypt, err)
+		}
+	}
+}
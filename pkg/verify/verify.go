@@ -0,0 +1,225 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"github.com/richardjennings/totp/pkg/hotp"
+	"github.com/richardjennings/totp/pkg/totp"
+	"hash"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrLockedOut matches a LockedOutError with errors.Is
+var ErrLockedOut = errors.New("locked out")
+
+// DefaultPolicy allows 5 failed attempts in 15 minutes before a 15 minute lockout, with a delay between attempts
+// starting at 1 second and doubling with each consecutive failure up to 1 minute.
+var DefaultPolicy = Policy{
+	MaxAttempts: 5,
+	Window:      15 * time.Minute,
+	Lockout:     15 * time.Minute,
+	Backoff:     time.Second,
+	MaxBackoff:  time.Minute,
+}
+
+type (
+	// Policy configures the throttling of failed verification attempts
+	Policy struct {
+		// the number of failed attempts within Window after which an account is locked out. 0 disables lockout
+		MaxAttempts int
+		// the period over which failed attempts are counted. 0 counts failed attempts until a successful attempt or a
+		// lockout, however far apart they are
+		Window time.Duration
+		// how long an account is locked out for once MaxAttempts is reached
+		Lockout time.Duration
+		// the delay required after a failed attempt, doubled for each further consecutive failure. 0 disables backoff
+		Backoff time.Duration
+		// the maximum delay required after a failed attempt
+		MaxBackoff time.Duration
+	}
+
+	// State is the verification state of an account
+	State struct {
+		// failed attempts counted since WindowStart
+		Failures    int
+		WindowStart time.Time
+		// failed attempts since the last successful attempt
+		Consecutive int
+		// no attempt is allowed before NextAttempt
+		NextAttempt time.Time
+	}
+
+	// StateStore stores the State of each account
+	StateStore interface {
+		// Get returns the State of account, or the zero State when none is stored
+		Get(account string) (State, error)
+		// Update atomically replaces the State of account with the result of fn applied to it
+		Update(account string, fn func(State) State) error
+	}
+
+	// MemoryStateStore is a StateStore held in memory
+	MemoryStateStore struct {
+		mu    sync.Mutex
+		state map[string]State
+	}
+
+	// Verifier throttles the verification of codes for accounts according to Policy, so that codes cannot be brute
+	// forced. Only failures due to an invalid or reused code count as failed attempts.
+	Verifier struct {
+		Policy Policy
+		Store  StateStore
+		// provides the current time. The system time is used when nil
+		Clock totp.Clock
+	}
+
+	// LockedOutError is returned when an account may not attempt verification until Until
+	LockedOutError struct {
+		Until time.Time
+	}
+)
+
+// Error implements error
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("locked out until %s", e.Until.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrLockedOut
+func (e *LockedOutError) Is(target error) bool {
+	return target == ErrLockedOut
+}
+
+// NewMemoryStateStore creates an empty MemoryStateStore
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{state: map[string]State{}}
+}
+
+// Get implements StateStore
+func (s *MemoryStateStore) Get(account string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state[account], nil
+}
+
+// Update implements StateStore
+func (s *MemoryStateStore) Update(account string, fn func(State) State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := fn(s.state[account])
+	if st == (State{}) {
+		delete(s.state, account)
+	} else {
+		s.state[account] = st
+	}
+	return nil
+}
+
+// Verify runs check for account unless it is locked out, in which case a *LockedOutError is returned without running
+// check. The attempt is recorded as a failure before check runs, so that concurrent attempts cannot exceed the Policy,
+// and is undone when check succeeds or fails for a reason other than a wrong code. The result of check is returned.
+func (v Verifier) Verify(account string, check func() error) error {
+	now := time.Now()
+	if v.Clock != nil {
+		now = v.Clock.Now()
+	}
+	var prev, pending State
+	var locked error
+	err := v.Store.Update(account, func(s State) State {
+		if now.Before(s.NextAttempt) {
+			locked = &LockedOutError{Until: s.NextAttempt}
+			return s
+		}
+		prev, pending = s, v.Policy.fail(s, now)
+		return pending
+	})
+	if err != nil {
+		return err
+	}
+	if locked != nil {
+		return locked
+	}
+	cerr := check()
+	if cerr == nil {
+		if err := v.Store.Update(account, func(State) State { return State{} }); err != nil {
+			return err
+		}
+		return nil
+	}
+	if !failed(cerr) {
+		// undo the reserved attempt unless another attempt has been recorded since
+		err = v.Store.Update(account, func(s State) State {
+			if s.equal(pending) {
+				return prev
+			}
+			return s
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return cerr
+}
+
+// VerifyTOTP validates code for account with validator, throttled by Verify
+func (v Verifier) VerifyTOTP(account string, validator totp.Validator, opts totp.Opts, code string) (offset int, err error) {
+	err = v.Verify(account, func() error {
+		offset, err = validator.Validate(account, opts, code)
+		return err
+	})
+	return offset, err
+}
+
+// VerifyHOTP verifies code for account with hotp.VerifyHOTP, throttled by Verify
+func (v Verifier) VerifyHOTP(account string, hash func() hash.Hash, secret []byte, code string, counter uint64, length uint, lookAhead uint) (next uint64, err error) {
+	next = counter
+	err = v.Verify(account, func() error {
+		next, err = hotp.VerifyHOTP(hash, secret, code, counter, length, lookAhead)
+		return err
+	})
+	return next, err
+}
+
+// fail returns the State following a failed attempt at now
+func (p Policy) fail(s State, now time.Time) State {
+	if p.Window > 0 && now.Sub(s.WindowStart) >= p.Window {
+		s.WindowStart = now
+		s.Failures = 0
+	}
+	s.Failures++
+	s.Consecutive++
+	s.NextAttempt = now
+	if p.Backoff > 0 {
+		d := p.Backoff
+		// stop doubling once the maximum is reached, or before overflowing
+		for i := 1; i < s.Consecutive && d <= math.MaxInt64/2; i++ {
+			d *= 2
+			if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+				break
+			}
+		}
+		if p.MaxBackoff > 0 && d > p.MaxBackoff {
+			d = p.MaxBackoff
+		}
+		s.NextAttempt = now.Add(d)
+	}
+	if p.MaxAttempts > 0 && s.Failures >= p.MaxAttempts {
+		if until := now.Add(p.Lockout); until.After(s.NextAttempt) {
+			s.NextAttempt = until
+		}
+		s.Failures = 0
+		s.WindowStart = now
+	}
+	return s
+}
+
+// equal reports whether s and o are the same State
+func (s State) equal(o State) bool {
+	return s.Failures == o.Failures && s.WindowStart.Equal(o.WindowStart) && s.Consecutive == o.Consecutive &&
+		s.NextAttempt.Equal(o.NextAttempt)
+}
+
+// failed reports whether err is due to a wrong code rather than, e.g., invalid options
+func failed(err error) bool {
+	return errors.Is(err, totp.ErrInvalidCode) || errors.Is(err, totp.ErrCodeReused) || errors.Is(err, hotp.ErrInvalidCode)
+}
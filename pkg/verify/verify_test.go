@@ -0,0 +1,203 @@
+package verify
+
+import (
+	"crypto/sha1"
+	"errors"
+	"github.com/richardjennings/totp/pkg/hotp"
+	"github.com/richardjennings/totp/pkg/totp"
+	"hash"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a totp.Clock which can be advanced
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.t
+}
+
+func TestVerifierBackoff(t *testing.T) {
+	c := &clock{t: time.Unix(1111111109, 0)}
+	v := Verifier{
+		Policy: Policy{Backoff: time.Second, MaxBackoff: 4 * time.Second},
+		Store:  NewMemoryStateStore(),
+		Clock:  c,
+	}
+	wrong := func() error { return totp.ErrInvalidCode }
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		if err := v.Verify("a", wrong); err != totp.ErrInvalidCode {
+			t.Fatalf("expected %v got %v", totp.ErrInvalidCode, err)
+		}
+		err := v.Verify("a", wrong)
+		var locked *LockedOutError
+		if !errors.As(err, &locked) || !errors.Is(err, ErrLockedOut) {
+			t.Fatalf("expected %v got %v", ErrLockedOut, err)
+		}
+		if !locked.Until.Equal(c.t.Add(delay)) {
+			t.Errorf("expected locked out until %s got %s", c.t.Add(delay), locked.Until)
+		}
+		c.t = locked.Until
+	}
+	// a successful attempt resets the backoff
+	if err := v.Verify("a", func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify("a", wrong); err != totp.ErrInvalidCode {
+		t.Fatalf("expected %v got %v", totp.ErrInvalidCode, err)
+	}
+	if err := v.Verify("a", wrong); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("expected %v got %v", ErrLockedOut, err)
+	}
+}
+
+func TestVerifierLockout(t *testing.T) {
+	c := &clock{t: time.Unix(1111111109, 0)}
+	v := Verifier{
+		Policy: Policy{MaxAttempts: 3, Window: time.Minute, Lockout: time.Hour},
+		Store:  NewMemoryStateStore(),
+		Clock:  c,
+	}
+	h := func() hash.Hash { return sha1.New() }
+	secret := []byte("12345678901234567890")
+	for i := 0; i < 3; i++ {
+		if _, err := v.VerifyHOTP("a", h, secret, "000000", 0, 6, 0); err != hotp.ErrInvalidCode {
+			t.Fatalf("expected %v got %v", hotp.ErrInvalidCode, err)
+		}
+	}
+	// the correct code is refused while locked out, and does not reveal that it is correct
+	if _, err := v.VerifyHOTP("a", h, secret, "755224", 0, 6, 0); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("expected %v got %v", ErrLockedOut, err)
+	}
+	// other accounts are unaffected
+	if next, err := v.VerifyHOTP("b", h, secret, "755224", 0, 6, 0); err != nil || next != 1 {
+		t.Fatalf("expected next 1 got %d %v", next, err)
+	}
+	c.t = c.t.Add(time.Hour)
+	if next, err := v.VerifyHOTP("a", h, secret, "755224", 0, 6, 0); err != nil || next != 1 {
+		t.Fatalf("expected next 1 got %d %v", next, err)
+	}
+
+	// failures outside of the window are not counted
+	for i := 0; i < 4; i++ {
+		if _, err := v.VerifyHOTP("a", h, secret, "000000", 0, 6, 0); err != hotp.ErrInvalidCode {
+			t.Fatalf("expected %v got %v", hotp.ErrInvalidCode, err)
+		}
+		c.t = c.t.Add(40 * time.Second)
+	}
+
+	// errors other than a wrong code are not counted
+	for i := 0; i < 4; i++ {
+		if _, err := v.VerifyHOTP("c", nil, secret, "000000", 0, 6, 0); err != hotp.ErrInvalidHash {
+			t.Fatalf("expected %v got %v", hotp.ErrInvalidHash, err)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	v := Verifier{Policy: DefaultPolicy, Store: NewMemoryStateStore()}
+	opts := totp.Opts{
+		Timestep:  30,
+		Secret:    []byte("12345678901234567890"),
+		Digits:    6,
+		Algorithm: totp.SHA1,
+		Clock:     totp.FixedClock{Time: time.Now()},
+	}
+	code, err := totp.GenerateTOTP(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := totp.Validator{Window: totp.Window{Behind: 1}, Used: totp.NewMemoryStore()}
+	if _, err := v.VerifyTOTP("a", validator, opts, code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.VerifyTOTP("a", validator, opts, code); err != totp.ErrCodeReused {
+		t.Fatalf("expected %v got %v", totp.ErrCodeReused, err)
+	}
+	if _, err := v.VerifyTOTP("a", validator, opts, code); !errors.Is(err, ErrLockedOut) {
+		t.Fatalf("expected %v got %v", ErrLockedOut, err)
+	}
+}
+
+func TestVerifierConcurrentLockout(t *testing.T) {
+	v := Verifier{
+		Policy: Policy{MaxAttempts: 1, Window: time.Minute, Lockout: time.Minute},
+		Store:  NewMemoryStateStore(),
+		Clock:  &clock{t: time.Unix(1111111109, 0)},
+	}
+	const n = 10
+	var checks int32
+	release := make(chan struct{})
+	results := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			results <- v.Verify("a", func() error {
+				atomic.AddInt32(&checks, 1)
+				<-release
+				return totp.ErrInvalidCode
+			})
+		}()
+	}
+	// every guess but the one being checked must be locked out without waiting for it
+	locked := 0
+	timeout := time.After(5 * time.Second)
+	for locked < n-1 {
+		select {
+		case err := <-results:
+			if !errors.Is(err, ErrLockedOut) {
+				t.Fatalf("expected %v got %v", ErrLockedOut, err)
+			}
+			locked++
+		case <-timeout:
+			close(release)
+			t.Fatalf("expected %d guesses to be locked out got %d", n-1, locked)
+		}
+	}
+	close(release)
+	if err := <-results; err != totp.ErrInvalidCode {
+		t.Errorf("expected %v got %v", totp.ErrInvalidCode, err)
+	}
+	if checks != 1 {
+		t.Errorf("expected 1 check got %d", checks)
+	}
+}
+
+func TestVerifierOtherErrorNotCounted(t *testing.T) {
+	v := Verifier{
+		Policy: Policy{MaxAttempts: 1, Window: time.Minute, Lockout: time.Minute},
+		Store:  NewMemoryStateStore(),
+		Clock:  &clock{t: time.Unix(1111111109, 0)},
+	}
+	other := errors.New("other")
+	for i := 0; i < 2; i++ {
+		if err := v.Verify("a", func() error { return other }); err != other {
+			t.Fatalf("expected %v got %v", other, err)
+		}
+	}
+	if s, _ := v.Store.Get("a"); s != (State{}) {
+		t.Errorf("expected no state got %+v", s)
+	}
+}
+
+func TestVerifierLockoutWithoutWindow(t *testing.T) {
+	c := &clock{t: time.Unix(1111111109, 0)}
+	v := Verifier{
+		Policy: Policy{MaxAttempts: 3, Lockout: time.Hour},
+		Store:  NewMemoryStateStore(),
+		Clock:  c,
+	}
+	wrong := func() error { return totp.ErrInvalidCode }
+	// without a window failures are counted however far apart they are
+	for i := 0; i < 3; i++ {
+		if err := v.Verify("a", wrong); err != totp.ErrInvalidCode {
+			t.Fatalf("expected %v got %v", totp.ErrInvalidCode, err)
+		}
+		c.t = c.t.Add(time.Minute)
+	}
+	if err := v.Verify("a", wrong); !errors.Is(err, ErrLockedOut) {
+		t.Errorf("expected %v got %v", ErrLockedOut, err)
+	}
+}